@@ -0,0 +1,61 @@
+package earlydecoder
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+func decodeCloudBlock(block *hcl.Block) (*module.CloudConfig, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(cloudSchema)
+
+	cloud := &module.CloudConfig{}
+
+	if attr, defined := content.Attributes["organization"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &cloud.Organization)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, defined := content.Attributes["hostname"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &cloud.Hostname)
+		diags = append(diags, valDiags...)
+	}
+
+	for _, innerBlock := range content.Blocks {
+		switch innerBlock.Type {
+		case "workspaces":
+			wsContent, _, wsDiags := innerBlock.Body.PartialContent(cloudWorkspacesSchema)
+			diags = append(diags, wsDiags...)
+
+			ws := &module.CloudWorkspaces{}
+
+			if attr, defined := wsContent.Attributes["name"]; defined {
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ws.Name)
+				diags = append(diags, valDiags...)
+			}
+
+			if attr, defined := wsContent.Attributes["tags"]; defined {
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ws.Tags)
+				diags = append(diags, valDiags...)
+			}
+
+			if attr, defined := wsContent.Attributes["project"]; defined {
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ws.Project)
+				diags = append(diags, valDiags...)
+			}
+
+			cloud.Workspaces = ws
+		}
+	}
+
+	return cloud, diags
+}
+
+func cloudBackendConflictDiagnostic(rng hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Both cloud and backend blocks found",
+		Detail:   "The cloud block and backend block are mutually exclusive, only one of them may be declared.",
+		Subject:  rng.Ptr(),
+	}
+}
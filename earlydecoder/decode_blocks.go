@@ -0,0 +1,96 @@
+package earlydecoder
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// BlockInfo describes a top-level block recognized by the early decoder
+type BlockInfo struct {
+	Type   string
+	Labels []string
+
+	// DefRange is the range of the block header,
+	// Range is the range of the whole block, which is only
+	// known for native syntax and equals DefRange in JSON
+	DefRange hcl.Range
+	Range    hcl.Range
+}
+
+// DecodeBlocks calls the handler for each top-level block of the file
+// which is part of the root schema, in order of declaration.
+//
+// Unlike LoadModule, block bodies are left undecoded and no maps
+// are built, which keeps memory use proportional to a single block
+// when indexing very large files. Iteration stops at the first error
+// returned by the handler, which is returned as is.
+func DecodeBlocks(file *hcl.File, handler func(BlockInfo) error) (hcl.Diagnostics, error) {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return decodeBlocksFromContent(file.Body, handler)
+	}
+
+	var diags hcl.Diagnostics
+
+	labelCounts := make(map[string]int, len(rootSchema.Blocks))
+	for _, bs := range rootSchema.Blocks {
+		labelCounts[bs.Type] = len(bs.LabelNames)
+	}
+
+	for _, block := range body.Blocks {
+		labelCount, ok := labelCounts[block.Type]
+		if !ok {
+			continue
+		}
+		if len(block.Labels) != labelCount {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid block labels",
+				Detail:   fmt.Sprintf("A %s block requires %d labels, %d given.", block.Type, labelCount, len(block.Labels)),
+				Subject:  block.DefRange().Ptr(),
+			})
+			continue
+		}
+
+		// The header range matches hcl.Block.DefRange, so it
+		// excludes the opening brace unlike block.DefRange()
+		lastHeaderRange := block.TypeRange
+		if len(block.LabelRanges) > 0 {
+			lastHeaderRange = block.LabelRanges[len(block.LabelRanges)-1]
+		}
+
+		err := handler(BlockInfo{
+			Type:     block.Type,
+			Labels:   block.Labels,
+			DefRange: hcl.RangeBetween(block.TypeRange, lastHeaderRange),
+			Range:    block.Range(),
+		})
+		if err != nil {
+			return diags, err
+		}
+	}
+
+	return diags, nil
+}
+
+// decodeBlocksFromContent is used for bodies other than native
+// syntax (i.e. JSON), whose blocks can only be listed via schema
+func decodeBlocksFromContent(body hcl.Body, handler func(BlockInfo) error) (hcl.Diagnostics, error) {
+	content, _, diags := body.PartialContent(rootSchema)
+
+	for _, block := range content.Blocks {
+		err := handler(BlockInfo{
+			Type:     block.Type,
+			Labels:   block.Labels,
+			DefRange: block.DefRange,
+			Range:    block.DefRange,
+		})
+		if err != nil {
+			return diags, err
+		}
+	}
+
+	return diags, nil
+}
@@ -0,0 +1,133 @@
+package earlydecoder
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestDecodeBlocks(t *testing.T) {
+	f := mustParseFile(t, "main.tf", `
+terraform {
+  required_version = ">= 1.0"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123456"
+}
+
+unknown "foo" {
+}
+
+module "vpc" {
+  source = "./vpc"
+}
+
+variable {
+}
+`)
+
+	blocks := make([]BlockInfo, 0)
+	diags, err := DecodeBlocks(f, func(b BlockInfo) error {
+		blocks = append(blocks, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Summary != "Invalid block labels" {
+		t.Fatalf("expected invalid labels diagnostic, given: %s", diags)
+	}
+
+	expectedBlocks := []BlockInfo{
+		{
+			Type:   "terraform",
+			Labels: nil,
+			DefRange: hcl.Range{
+				Filename: "main.tf",
+				Start:    hcl.Pos{Line: 2, Column: 1, Byte: 1},
+				End:      hcl.Pos{Line: 2, Column: 10, Byte: 10},
+			},
+			Range: hcl.Range{
+				Filename: "main.tf",
+				Start:    hcl.Pos{Line: 2, Column: 1, Byte: 1},
+				End:      hcl.Pos{Line: 4, Column: 2, Byte: 44},
+			},
+		},
+		{
+			Type:   "resource",
+			Labels: []string{"aws_instance", "web"},
+			DefRange: hcl.Range{
+				Filename: "main.tf",
+				Start:    hcl.Pos{Line: 6, Column: 1, Byte: 46},
+				End:      hcl.Pos{Line: 6, Column: 30, Byte: 75},
+			},
+			Range: hcl.Range{
+				Filename: "main.tf",
+				Start:    hcl.Pos{Line: 6, Column: 1, Byte: 46},
+				End:      hcl.Pos{Line: 8, Column: 2, Byte: 100},
+			},
+		},
+		{
+			Type:   "module",
+			Labels: []string{"vpc"},
+			DefRange: hcl.Range{
+				Filename: "main.tf",
+				Start:    hcl.Pos{Line: 13, Column: 1, Byte: 121},
+				End:      hcl.Pos{Line: 13, Column: 13, Byte: 133},
+			},
+			Range: hcl.Range{
+				Filename: "main.tf",
+				Start:    hcl.Pos{Line: 13, Column: 1, Byte: 121},
+				End:      hcl.Pos{Line: 15, Column: 2, Byte: 156},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedBlocks, blocks); diff != "" {
+		t.Fatalf("unexpected blocks: %s", diff)
+	}
+	// header ranges are consistent with declaration ranges of LoadModule
+	meta, _ := LoadModule("path", map[string]*hcl.File{"main.tf": f})
+	if diff := cmp.Diff(meta.Resources["aws_instance.web"].DeclRange, blocks[1].DefRange); diff != "" {
+		t.Fatalf("header range doesn't match resource declaration range: %s", diff)
+	}
+}
+
+func TestDecodeBlocks_handlerError(t *testing.T) {
+	f := mustParseFile(t, "main.tf", `
+resource "aws_instance" "a" {}
+resource "aws_instance" "b" {}
+`)
+
+	stopErr := errors.New("stop")
+	count := 0
+	_, err := DecodeBlocks(f, func(b BlockInfo) error {
+		count++
+		return stopErr
+	})
+	if err != stopErr {
+		t.Fatalf("expected handler error, given: %#v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected iteration to stop after 1 block, %d given", count)
+	}
+}
+
+func BenchmarkDecodeBlocks(b *testing.B) {
+	f := mustParseFile(b, "main.tf", syntheticModuleConfig(2000))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		_, err := DecodeBlocks(f, func(BlockInfo) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
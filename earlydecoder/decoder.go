@@ -2,43 +2,63 @@ package earlydecoder
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"github.com/hashicorp/terraform-registry-address"
 	"github.com/hashicorp/terraform-schema/module"
 )
 
+// LoadModule decodes the given parsed files of the module in the given path.
+//
+// Files which failed to parse may still be passed in, in which case
+// any blocks the parser recovered are decoded, such that a syntax error
+// in one block doesn't hide the rest of the module. The parser diagnostics
+// are not part of the returned diagnostics. Nil files are skipped.
 func LoadModule(path string, files map[string]*hcl.File) (*module.Meta, hcl.Diagnostics) {
+	d := NewModuleDecoder(path)
+	for filename, file := range files {
+		d.UpdateFile(filename, file)
+	}
+	return d.Meta()
+}
+
+// buildMeta validates the given module merged from all files
+// and turns it into module metadata
+func buildMeta(path string, mod *decodedModule) (*module.Meta, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
-	mod := tfconfig.NewModule(path)
-	for _, f := range files {
-		fDiags := tfconfig.LoadModuleFromFile(f, mod)
-		diags = append(diags, fDiags...)
-	}
-
-	var coreRequirements version.Constraints
-	for _, rc := range mod.RequiredCore {
-		c, err := version.NewConstraint(rc)
-		if err != nil {
-			diags = append(diags, &hcl.Diagnostic{
-				Severity: hcl.DiagError,
-				Summary:  "Unable to parse terraform requirements",
-				Detail:   fmt.Sprintf("Constraint %q is not a valid constraint: %s", rc, err),
-			})
-			continue
-		}
-		coreRequirements = append(coreRequirements, c...)
+	inferProviderNames(mod)
+
+	diags = append(diags, validateRemovedBlocks(mod)...)
+	diags = append(diags, validateProviderMetas(mod)...)
+	diags = append(diags, validateProviderAliases(mod)...)
+
+	var requiredCore []string
+	if len(mod.RequiredCore) > 0 {
+		requiredCore = mod.RequiredCore
 	}
+	coreRequirements, coreDiags := module.ParseCoreConstraints(requiredCore)
+	diags = append(diags, coreDiags...)
+	diags = append(diags, validateCoreRequirements(coreRequirements)...)
 
 	var (
 		providerRequirements = make(map[tfaddr.Provider]version.Constraints, 0)
 		refs                 = make(map[module.ProviderRef]tfaddr.Provider, 0)
+		requiredProviders    = make(map[string]*module.ProviderRequirement, 0)
 	)
 
-	for name, req := range mod.RequiredProviders {
+	for name, req := range mod.ProviderRequirements {
+		requiredProviders[name] = &module.ProviderRequirement{
+			Source:               req.Source,
+			VersionConstraints:   req.VersionConstraints,
+			ConfigurationAliases: req.ConfigurationAliases,
+			DeclRange:            req.DeclRange,
+			DeclRanges:           req.DeclRanges,
+		}
+
 		var src tfaddr.Provider
 
 		if req.Source == "" {
@@ -47,8 +67,14 @@ func LoadModule(path string, files map[string]*hcl.File) (*module.Meta, hcl.Diag
 			}
 			src = tfaddr.NewLegacyProvider(name)
 		} else {
-			var err error
-			src, err = tfaddr.ParseRawProviderSourceString(req.Source)
+			// Terraform folds the case of sources, so DataDog/datadog is valid
+			// even though ParseProviderSource only accepts lowercase parts
+			ps, err := module.ParseProviderSource(strings.ToLower(req.Source))
+			if err == nil {
+				// the normalized source is always fully qualified
+				// and so never interpreted as a legacy provider
+				src, err = tfaddr.ParseRawProviderSourceString(ps.String())
+			}
 			if err != nil {
 				diags = append(diags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
@@ -59,19 +85,11 @@ func LoadModule(path string, files map[string]*hcl.File) (*module.Meta, hcl.Diag
 			}
 		}
 
-		constraints := make(version.Constraints, 0)
-		for _, vc := range req.VersionConstraints {
-			c, err := version.NewConstraint(vc)
-			if err != nil {
-				diags = append(diags, &hcl.Diagnostic{
-					Severity: hcl.DiagError,
-					Summary:  fmt.Sprintf("Unable to parse %q provider requirements", name),
-					Detail:   fmt.Sprintf("Constraint %q is not a valid constraint: %s", vc, err),
-				})
-				continue
-			}
-			constraints = append(constraints, c...)
+		constraints, cDiags := requiredProviders[name].EffectiveConstraints()
+		for _, diag := range cDiags {
+			diag.Summary = fmt.Sprintf("Unable to parse %q provider requirements", name)
 		}
+		diags = append(diags, cDiags...)
 
 		providerRequirements[src] = constraints
 
@@ -81,13 +99,22 @@ func LoadModule(path string, files map[string]*hcl.File) (*module.Meta, hcl.Diag
 
 		for _, alias := range req.ConfigurationAliases {
 			refs[module.ProviderRef{
-				LocalName: alias.Name,
+				LocalName: alias.LocalName,
 				Alias:     alias.Alias,
 			}] = src
 		}
 	}
 
+	providerConfigs := make([]*module.ProviderConfig, 0, len(mod.ProviderConfigs))
 	for _, cfg := range mod.ProviderConfigs {
+		providerConfigs = append(providerConfigs, &module.ProviderConfig{
+			LocalName:        cfg.Name,
+			Alias:            cfg.Alias,
+			AliasUnknown:     cfg.AliasUnknown,
+			NestedBlockTypes: cfg.NestedBlockTypes,
+			DeclRange:        cfg.DeclRange,
+		})
+
 		src := refs[module.ProviderRef{
 			LocalName: cfg.Name,
 		}]
@@ -99,8 +126,8 @@ func LoadModule(path string, files map[string]*hcl.File) (*module.Meta, hcl.Diag
 		}
 	}
 
-	for _, resource := range mod.ManagedResources {
-		providerName := resource.Provider.Name
+	for _, resource := range mod.Resources {
+		providerName := resource.Provider.LocalName
 		localRef := module.ProviderRef{
 			LocalName: providerName,
 		}
@@ -112,10 +139,43 @@ func LoadModule(path string, files map[string]*hcl.File) (*module.Meta, hcl.Diag
 
 			refs[localRef] = src
 		}
+		resource.ProviderAddr = resolveProviderAddr(refs, resource.Provider)
+	}
+
+	for _, dataSource := range mod.DataSources {
+		providerName := dataSource.Provider.LocalName
+		localRef := module.ProviderRef{
+			LocalName: providerName,
+		}
+		if _, exists := refs[localRef]; !exists && providerName != "" {
+			src := tfaddr.NewLegacyProvider(providerName)
+			if _, exists := providerRequirements[src]; !exists {
+				providerRequirements[src] = version.Constraints{}
+			}
+			refs[localRef] = src
+		}
+		dataSource.ProviderAddr = resolveProviderAddr(refs, dataSource.Provider)
+	}
+
+	for _, c := range mod.Checks {
+		for _, dataSource := range c.ScopedDataSources {
+			providerName := dataSource.Provider.LocalName
+			localRef := module.ProviderRef{
+				LocalName: providerName,
+			}
+			if _, exists := refs[localRef]; !exists && providerName != "" {
+				src := tfaddr.NewLegacyProvider(providerName)
+				if _, exists := providerRequirements[src]; !exists {
+					providerRequirements[src] = version.Constraints{}
+				}
+				refs[localRef] = src
+			}
+			dataSource.ProviderAddr = resolveProviderAddr(refs, dataSource.Provider)
+		}
 	}
 
-	for _, dataSource := range mod.DataResources {
-		providerName := dataSource.Provider.Name
+	for _, er := range mod.EphemeralResources {
+		providerName := er.Provider.LocalName
 		localRef := module.ProviderRef{
 			LocalName: providerName,
 		}
@@ -126,6 +186,31 @@ func LoadModule(path string, files map[string]*hcl.File) (*module.Meta, hcl.Diag
 			}
 			refs[localRef] = src
 		}
+		er.ProviderAddr = resolveProviderAddr(refs, er.Provider)
+	}
+
+	sort.Slice(providerConfigs, func(i, j int) bool {
+		if providerConfigs[i].LocalName != providerConfigs[j].LocalName {
+			return providerConfigs[i].LocalName < providerConfigs[j].LocalName
+		}
+		if providerConfigs[i].Alias != providerConfigs[j].Alias {
+			return providerConfigs[i].Alias < providerConfigs[j].Alias
+		}
+		// configurations with unknown aliases go last, in order of declaration
+		if providerConfigs[i].AliasUnknown != providerConfigs[j].AliasUnknown {
+			return providerConfigs[j].AliasUnknown
+		}
+		return rangeLess(providerConfigs[i].DeclRange, providerConfigs[j].DeclRange)
+	})
+
+	providerMeta := make(map[string]hcl.Body, len(mod.ProviderMetas))
+	for name, pm := range mod.ProviderMetas {
+		providerMeta[name] = pm.Body
+	}
+
+	locals := make(map[string]hcl.Expression, len(mod.Locals))
+	for name, attr := range mod.Locals {
+		locals[name] = attr.Expr
 	}
 
 	return &module.Meta{
@@ -133,5 +218,94 @@ func LoadModule(path string, files map[string]*hcl.File) (*module.Meta, hcl.Diag
 		ProviderReferences:   refs,
 		ProviderRequirements: providerRequirements,
 		CoreRequirements:     coreRequirements,
+		RequiredCore:         requiredCore,
+		Experiments:          mod.Experiments,
+		RequiredProviders:    requiredProviders,
+		ProviderConfigs:      providerConfigs,
+		Resources:            mod.Resources,
+		DataSources:          mod.DataSources,
+		EphemeralResources:   mod.EphemeralResources,
+		Checks:               mod.Checks,
+		Locals:               locals,
+		MovedBlocks:          mod.MovedBlocks,
+		Imports:              mod.Imports,
+		Removed:              mod.Removed,
+		Backend:              mod.Backend,
+		Cloud:                mod.Cloud,
+		ModuleSources:        mod.ModuleSources,
+		Variables:            mod.Variables,
+		Outputs:              mod.Outputs,
+		ProviderMeta:         providerMeta,
 	}, diags
 }
+
+// resolveProviderAddr returns the address of the provider the given
+// reference points to, falling back to the default (unaliased) provider
+// of the same local name if the alias is not declared
+func resolveProviderAddr(refs map[module.ProviderRef]tfaddr.Provider, ref module.ProviderRef) tfaddr.Provider {
+	if addr, ok := refs[ref]; ok {
+		return addr
+	}
+	return refs[module.ProviderRef{LocalName: ref.LocalName}]
+}
+
+// rangeLess returns true if range a starts before range b,
+// ordering ranges in different files by filename
+func rangeLess(a, b hcl.Range) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.Start.Byte < b.Start.Byte
+}
+
+// inferProviderNames revisits provider local names of resources,
+// data sources and ephemeral resources without an explicit provider
+// argument, now that required_providers of all files are known
+func inferProviderNames(mod *decodedModule) {
+	if len(mod.ProviderRequirements) == 0 {
+		return
+	}
+
+	for key, r := range mod.Resources {
+		if _, explicit := mod.ProviderAttrRanges[key]; !explicit {
+			r.Provider.LocalName = inferDeclaredProviderName(r.Type, mod.ProviderRequirements)
+		}
+	}
+	for key, ds := range mod.DataSources {
+		if _, explicit := mod.ProviderAttrRanges[key]; !explicit {
+			ds.Provider.LocalName = inferDeclaredProviderName(ds.Type, mod.ProviderRequirements)
+		}
+	}
+	for _, c := range mod.Checks {
+		for key, ds := range c.ScopedDataSources {
+			if _, explicit := mod.ProviderAttrRanges[checkScopedKey(c.Name, key)]; !explicit {
+				ds.Provider.LocalName = inferDeclaredProviderName(ds.Type, mod.ProviderRequirements)
+			}
+		}
+	}
+	for key, er := range mod.EphemeralResources {
+		if _, explicit := mod.ProviderAttrRanges[key]; !explicit {
+			er.Provider.LocalName = inferDeclaredProviderName(er.Type, mod.ProviderRequirements)
+		}
+	}
+}
+
+// inferDeclaredProviderName returns the longest declared provider
+// local name which prefixes the given type, such that google_beta_foo
+// maps to google_beta rather than google if both are declared.
+// It falls back to the name implied by the first underscore.
+func inferDeclaredProviderName(typeName string, requirements map[string]*providerRequirement) string {
+	longest := ""
+	for localName := range requirements {
+		if len(localName) <= len(longest) {
+			continue
+		}
+		if typeName == localName || strings.HasPrefix(typeName, localName+"_") {
+			longest = localName
+		}
+	}
+	if longest != "" {
+		return longest
+	}
+	return inferProviderNameFromType(typeName)
+}
@@ -1,15 +1,23 @@
 package earlydecoder
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
 	"github.com/hashicorp/terraform-registry-address"
 	"github.com/hashicorp/terraform-schema/module"
+	"github.com/zclconf/go-cty-debug/ctydebug"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestLoadModule(t *testing.T) {
@@ -27,6 +35,21 @@ func TestLoadModule(t *testing.T) {
 				Path:                 path,
 				ProviderReferences:   map[module.ProviderRef]tfaddr.Provider{},
 				ProviderRequirements: map[tfaddr.Provider]version.Constraints{},
+				RequiredProviders:    map[string]*module.ProviderRequirement{},
+				Resources:            map[string]*module.Resource{},
+				DataSources:          map[string]*module.DataSource{},
+				ModuleSources:        map[string]*module.ModuleSource{},
+				Variables:            map[string]*module.Variable{},
+				Outputs:              map[string]*module.Output{},
+				ProviderMeta:         map[string]hcl.Body{},
+				Experiments:          []string{},
+				ProviderConfigs:      []*module.ProviderConfig{},
+				EphemeralResources:   map[string]*module.EphemeralResource{},
+				Checks:               map[string]*module.Check{},
+				Locals:               map[string]hcl.Expression{},
+				MovedBlocks:          []*module.Moved{},
+				Imports:              []*module.Import{},
+				Removed:              []*module.Removed{},
 			},
 		},
 		{
@@ -38,8 +61,24 @@ terraform {
 			&module.Meta{
 				Path:                 path,
 				CoreRequirements:     mustConstraints(t, "~> 0.12"),
+				RequiredCore:         []string{"~> 0.12"},
 				ProviderReferences:   map[module.ProviderRef]tfaddr.Provider{},
 				ProviderRequirements: map[tfaddr.Provider]version.Constraints{},
+				RequiredProviders:    map[string]*module.ProviderRequirement{},
+				Resources:            map[string]*module.Resource{},
+				DataSources:          map[string]*module.DataSource{},
+				ModuleSources:        map[string]*module.ModuleSource{},
+				Variables:            map[string]*module.Variable{},
+				Outputs:              map[string]*module.Output{},
+				ProviderMeta:         map[string]hcl.Body{},
+				Experiments:          []string{},
+				ProviderConfigs:      []*module.ProviderConfig{},
+				EphemeralResources:   map[string]*module.EphemeralResource{},
+				Checks:               map[string]*module.Check{},
+				Locals:               map[string]hcl.Expression{},
+				MovedBlocks:          []*module.Moved{},
+				Imports:              []*module.Import{},
+				Removed:              []*module.Removed{},
 			},
 		},
 		{
@@ -76,6 +115,41 @@ provider "grafana" {
 					tfaddr.NewLegacyProvider("google"):  {},
 					tfaddr.NewLegacyProvider("grafana"): {},
 				},
+				RequiredProviders: map[string]*module.ProviderRequirement{
+					"aws":     {},
+					"grafana": {},
+				},
+				Resources: map[string]*module.Resource{
+					"google_storage_bucket.bucket": {
+						Type:         "google_storage_bucket",
+						Name:         "bucket",
+						Provider:     module.ProviderRef{LocalName: "google"},
+						ProviderAddr: tfaddr.NewLegacyProvider("google"),
+					},
+				},
+				DataSources: map[string]*module.DataSource{
+					"data.blah_foobar.test": {
+						Type:         "blah_foobar",
+						Name:         "test",
+						Provider:     module.ProviderRef{LocalName: "blah"},
+						ProviderAddr: tfaddr.NewLegacyProvider("blah"),
+					},
+				},
+				ModuleSources: map[string]*module.ModuleSource{},
+				Variables:     map[string]*module.Variable{},
+				Outputs:       map[string]*module.Output{},
+				ProviderMeta:  map[string]hcl.Body{},
+				Experiments:   []string{},
+				ProviderConfigs: []*module.ProviderConfig{
+					{LocalName: "aws"},
+					{LocalName: "grafana"},
+				},
+				EphemeralResources: map[string]*module.EphemeralResource{},
+				Checks:             map[string]*module.Check{},
+				Locals:             map[string]hcl.Expression{},
+				MovedBlocks:        []*module.Moved{},
+				Imports:            []*module.Import{},
+				Removed:            []*module.Removed{},
 			},
 		},
 		{
@@ -112,6 +186,35 @@ provider "grafana" {
 					tfaddr.NewLegacyProvider("google"):  mustConstraints(t, ">= 3.0.0"),
 					tfaddr.NewLegacyProvider("grafana"): {},
 				},
+				RequiredProviders: map[string]*module.ProviderRequirement{
+					"aws":     {VersionConstraints: []string{"1.2.0"}},
+					"google":  {VersionConstraints: []string{">= 3.0.0"}},
+					"grafana": {},
+				},
+				Resources: map[string]*module.Resource{
+					"google_storage_bucket.bucket": {
+						Type:         "google_storage_bucket",
+						Name:         "bucket",
+						Provider:     module.ProviderRef{LocalName: "google"},
+						ProviderAddr: tfaddr.NewLegacyProvider("google"),
+					},
+				},
+				DataSources:   map[string]*module.DataSource{},
+				ModuleSources: map[string]*module.ModuleSource{},
+				Variables:     map[string]*module.Variable{},
+				Outputs:       map[string]*module.Output{},
+				ProviderMeta:  map[string]hcl.Body{},
+				Experiments:   []string{},
+				ProviderConfigs: []*module.ProviderConfig{
+					{LocalName: "aws"},
+					{LocalName: "grafana"},
+				},
+				EphemeralResources: map[string]*module.EphemeralResource{},
+				Checks:             map[string]*module.Check{},
+				Locals:             map[string]hcl.Expression{},
+				MovedBlocks:        []*module.Moved{},
+				Imports:            []*module.Import{},
+				Removed:            []*module.Removed{},
 			},
 		},
 		{
@@ -182,6 +285,48 @@ provider "grafana" {
 						Type:      "grafana",
 					}: mustConstraints(t, "2.1.0"),
 				},
+				RequiredProviders: map[string]*module.ProviderRequirement{
+					"aws": {
+						Source:             "hashicorp/aws",
+						VersionConstraints: []string{"1.0.0"},
+					},
+					"google": {
+						Source:             "hashicorp/google",
+						VersionConstraints: []string{"2.0.0"},
+					},
+					"grafana": {
+						Source:             "grafana/grafana",
+						VersionConstraints: []string{"2.1.0"},
+					},
+				},
+				Resources: map[string]*module.Resource{
+					"google_storage_bucket.bucket": {
+						Type:     "google_storage_bucket",
+						Name:     "bucket",
+						Provider: module.ProviderRef{LocalName: "google"},
+						ProviderAddr: tfaddr.Provider{
+							Hostname:  tfaddr.DefaultRegistryHost,
+							Namespace: "hashicorp",
+							Type:      "google",
+						},
+					},
+				},
+				DataSources:   map[string]*module.DataSource{},
+				ModuleSources: map[string]*module.ModuleSource{},
+				Variables:     map[string]*module.Variable{},
+				Outputs:       map[string]*module.Output{},
+				ProviderMeta:  map[string]hcl.Body{},
+				Experiments:   []string{},
+				ProviderConfigs: []*module.ProviderConfig{
+					{LocalName: "aws"},
+					{LocalName: "grafana"},
+				},
+				EphemeralResources: map[string]*module.EphemeralResource{},
+				Checks:             map[string]*module.Check{},
+				Locals:             map[string]hcl.Expression{},
+				MovedBlocks:        []*module.Moved{},
+				Imports:            []*module.Import{},
+				Removed:            []*module.Removed{},
 			},
 		},
 		{
@@ -235,6 +380,32 @@ provider "aws" {
 						Type:      "google",
 					}: mustConstraints(t, "2.0.0"),
 				},
+				RequiredProviders: map[string]*module.ProviderRequirement{
+					"aws": {
+						Source:             "hashicorp/aws",
+						VersionConstraints: []string{"1.0.0"},
+					},
+					"google": {
+						Source:             "hashicorp/google",
+						VersionConstraints: []string{"2.0.0"},
+					},
+				},
+				Resources:     map[string]*module.Resource{},
+				DataSources:   map[string]*module.DataSource{},
+				ModuleSources: map[string]*module.ModuleSource{},
+				Variables:     map[string]*module.Variable{},
+				Outputs:       map[string]*module.Output{},
+				ProviderMeta:  map[string]hcl.Body{},
+				Experiments:   []string{},
+				ProviderConfigs: []*module.ProviderConfig{
+					{LocalName: "aws", Alias: "euwest"},
+				},
+				EphemeralResources: map[string]*module.EphemeralResource{},
+				Checks:             map[string]*module.Check{},
+				Locals:             map[string]hcl.Expression{},
+				MovedBlocks:        []*module.Moved{},
+				Imports:            []*module.Import{},
+				Removed:            []*module.Removed{},
 			},
 		},
 		{
@@ -294,11 +465,45 @@ provider "aws" {
 						Type:      "google",
 					}: mustConstraints(t, "2.0.0"),
 				},
+				RequiredProviders: map[string]*module.ProviderRequirement{
+					"aws": {
+						Source:             "hashicorp/aws",
+						VersionConstraints: []string{"1.0.0"},
+						ConfigurationAliases: []module.ProviderRef{
+							{LocalName: "aws", Alias: "east"},
+						},
+					},
+					"google": {
+						Source:             "hashicorp/google",
+						VersionConstraints: []string{"2.0.0"},
+					},
+				},
+				Resources:     map[string]*module.Resource{},
+				DataSources:   map[string]*module.DataSource{},
+				ModuleSources: map[string]*module.ModuleSource{},
+				Variables:     map[string]*module.Variable{},
+				Outputs:       map[string]*module.Output{},
+				ProviderMeta:  map[string]hcl.Body{},
+				Experiments:   []string{},
+				ProviderConfigs: []*module.ProviderConfig{
+					{LocalName: "aws", Alias: "west"},
+				},
+				EphemeralResources: map[string]*module.EphemeralResource{},
+				Checks:             map[string]*module.Check{},
+				Locals:             map[string]hcl.Expression{},
+				MovedBlocks:        []*module.Moved{},
+				Imports:            []*module.Import{},
+				Removed:            []*module.Removed{},
 			},
 		},
 	}
 
-	opts := cmp.Comparer(compareVersionConstraint)
+	opts := cmp.Options{
+		cmp.Comparer(compareVersionConstraint),
+		// bodies are decoded by callers, see TestLoadModule_resourceBody
+		cmpopts.IgnoreFields(module.Resource{}, "Body"),
+		cmpopts.IgnoreFields(module.DataSource{}, "Body"),
+	}
 
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("%d-%s", i, tc.name), func(t *testing.T) {
@@ -315,7 +520,9 @@ provider "aws" {
 				t.Fatal(diags)
 			}
 
-			if diff := cmp.Diff(tc.expectedMeta, meta, opts); diff != "" {
+			// Meta is compared by value, since go-cmp would otherwise
+			// use (*Meta).Equal, which ignores expressions
+			if diff := cmp.Diff(*tc.expectedMeta, *meta, opts, ignoreDeclRanges); diff != "" {
 				t.Fatalf("module meta doesn't match: %s", diff)
 			}
 		})
@@ -333,3 +540,1546 @@ func mustConstraints(t *testing.T, vc string) version.Constraints {
 func compareVersionConstraint(x, y version.Constraint) bool {
 	return x.String() == y.String()
 }
+
+// ignoreDeclRanges ignores ranges of blocks, which are tested separately
+var ignoreDeclRanges = cmp.Options{
+	cmpopts.IgnoreFields(module.Resource{}, "DeclRange"),
+	cmpopts.IgnoreFields(module.DataSource{}, "DeclRange"),
+	cmpopts.IgnoreFields(module.ModuleSource{}, "DeclRange"),
+	cmpopts.IgnoreFields(module.Variable{}, "DeclRange"),
+	cmpopts.IgnoreFields(module.Output{}, "DeclRange"),
+	cmpopts.IgnoreFields(module.ProviderConfig{}, "DeclRange"),
+	cmpopts.IgnoreFields(module.ProviderRequirement{}, "DeclRange", "DeclRanges"),
+	cmpopts.IgnoreFields(module.StackComponent{}, "DeclRange"),
+	cmpopts.IgnoreFields(module.StackDeployment{}, "DeclRange"),
+}
+
+func TestLoadModule_removedStillDeclared(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+resource "aws_instance" "web" {}
+`),
+		"removed.tf": mustParseFile(t, "removed.tf", `
+removed {
+  from = aws_instance.web
+}
+
+removed {
+  from = aws_instance.gone
+}
+`),
+	}
+
+	_, diags := LoadModule(t.TempDir(), files)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Severity != hcl.DiagWarning {
+		t.Fatalf("expected warning, given: %#v", diags[0].Severity)
+	}
+	if diags[0].Subject.Filename != "removed.tf" {
+		t.Fatalf("expected diagnostic to point to removed.tf, given: %s", diags[0].Subject)
+	}
+}
+
+func TestLoadModule_providerSources(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source = "aws"
+    }
+    grafana = {
+      source = "grafana/grafana"
+    }
+    example = {
+      source = "app.terraform.io/example-corp/example"
+    }
+    datadog = {
+      source = "DataDog/datadog"
+    }
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedRefs := map[module.ProviderRef]tfaddr.Provider{
+		{LocalName: "aws"}: {
+			Hostname:  tfaddr.DefaultRegistryHost,
+			Namespace: "hashicorp",
+			Type:      "aws",
+		},
+		{LocalName: "grafana"}: {
+			Hostname:  tfaddr.DefaultRegistryHost,
+			Namespace: "grafana",
+			Type:      "grafana",
+		},
+		{LocalName: "example"}: {
+			Hostname:  "app.terraform.io",
+			Namespace: "example-corp",
+			Type:      "example",
+		},
+		{LocalName: "datadog"}: {
+			Hostname:  tfaddr.DefaultRegistryHost,
+			Namespace: "datadog",
+			Type:      "datadog",
+		},
+	}
+	if diff := cmp.Diff(expectedRefs, meta.ProviderReferences); diff != "" {
+		t.Fatalf("provider references don't match: %s", diff)
+	}
+
+	datadog := tfaddr.NewProvider(tfaddr.DefaultRegistryHost, "datadog", "datadog")
+	if _, ok := meta.ProviderRequirements[datadog]; !ok {
+		t.Fatalf("expected requirement of %s, given: %#v", datadog, meta.ProviderRequirements)
+	}
+}
+
+func TestLoadModule_invalidProviderSource(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source = "hashi_corp/aws"
+    }
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != `Unable to parse provider source for "aws"` {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if len(meta.ProviderRequirements) != 0 {
+		t.Fatalf("expected no provider requirements, given: %#v", meta.ProviderRequirements)
+	}
+}
+
+func TestLoadModule_conflictingCoreRequirements(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mainCfg       string
+		versionsCfg   string
+		expectedDiags int
+	}{
+		{
+			"contradictory ranges",
+			`terraform {
+  required_version = ">= 1.0"
+}`,
+			`terraform {
+  required_version = "< 0.13"
+}`,
+			1,
+		},
+		{
+			"contradictory pessimistic constraint",
+			`terraform {
+  required_version = "~> 0.12.0"
+}`,
+			`terraform {
+  required_version = ">= 0.13"
+}`,
+			1,
+		},
+		{
+			"exclusive bounds meeting",
+			`terraform {
+  required_version = "> 1.0"
+}`,
+			`terraform {
+  required_version = "<= 1.0"
+}`,
+			1,
+		},
+		{
+			"overlapping ranges",
+			`terraform {
+  required_version = ">= 0.12"
+}`,
+			`terraform {
+  required_version = "~> 0.15, != 0.15.1"
+}`,
+			0,
+		},
+		{
+			"exact version within range",
+			`terraform {
+  required_version = "1.0.0"
+}`,
+			`terraform {
+  required_version = ">= 1.0, < 1.1"
+}`,
+			0,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.name), func(t *testing.T) {
+			files := map[string]*hcl.File{
+				"main.tf":     mustParseFile(t, "main.tf", tc.mainCfg),
+				"versions.tf": mustParseFile(t, "versions.tf", tc.versionsCfg),
+			}
+
+			_, diags := LoadModule(t.TempDir(), files)
+			if len(diags) != tc.expectedDiags {
+				t.Fatalf("expected %d diagnostics, %d given: %s", tc.expectedDiags, len(diags), diags)
+			}
+			if tc.expectedDiags > 0 && diags[0].Summary != "Conflicting terraform requirements" {
+				t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+			}
+		})
+	}
+}
+
+func TestLoadModule_multipleFiles(t *testing.T) {
+	path := t.TempDir()
+
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_version = ">= 0.15"
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 3.0"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "eu-west-2"
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "3.1.0"
+}
+
+module "local" {
+  source = "./modules/local"
+}
+`),
+		"variables.tf": mustParseFile(t, "variables.tf", `
+variable "name" {
+  type = string
+}
+`),
+		"outputs.tf": mustParseFile(t, "outputs.tf", `
+output "vpc_id" {
+  value = module.vpc.vpc_id
+}
+`),
+	}
+
+	meta, diags := LoadModule(path, files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	awsProvider := tfaddr.Provider{
+		Hostname:  tfaddr.DefaultRegistryHost,
+		Namespace: "hashicorp",
+		Type:      "aws",
+	}
+	expectedMeta := &module.Meta{
+		Path:             path,
+		CoreRequirements: mustConstraints(t, ">= 0.15"),
+		RequiredCore:     []string{">= 0.15"},
+		ProviderReferences: map[module.ProviderRef]tfaddr.Provider{
+			{LocalName: "aws"}:                awsProvider,
+			{LocalName: "aws", Alias: "west"}: awsProvider,
+		},
+		ProviderRequirements: map[tfaddr.Provider]version.Constraints{
+			awsProvider: mustConstraints(t, "~> 3.0"),
+		},
+		RequiredProviders: map[string]*module.ProviderRequirement{
+			"aws": {
+				Source:             "hashicorp/aws",
+				VersionConstraints: []string{"~> 3.0"},
+			},
+		},
+		Resources:   map[string]*module.Resource{},
+		DataSources: map[string]*module.DataSource{},
+		ModuleSources: map[string]*module.ModuleSource{
+			"module.vpc": {
+				Name:    "vpc",
+				Source:  "terraform-aws-modules/vpc/aws",
+				Version: "3.1.0",
+			},
+			"module.local": {
+				Name:   "local",
+				Source: "./modules/local",
+			},
+		},
+		Variables: map[string]*module.Variable{
+			"name": {
+				Name:       "name",
+				Type:       cty.String,
+				IsNullable: true,
+			},
+		},
+		Outputs: map[string]*module.Output{
+			"vpc_id": {
+				Name: "vpc_id",
+			},
+		},
+		ProviderMeta: map[string]hcl.Body{},
+		Experiments:  []string{},
+		ProviderConfigs: []*module.ProviderConfig{
+			{LocalName: "aws", Alias: "west"},
+		},
+		EphemeralResources: map[string]*module.EphemeralResource{},
+		Checks:             map[string]*module.Check{},
+		Locals:             map[string]hcl.Expression{},
+		MovedBlocks:        []*module.Moved{},
+		Imports:            []*module.Import{},
+		Removed:            []*module.Removed{},
+	}
+
+	opts := cmp.Options{
+		cmp.Comparer(compareVersionConstraint),
+		cmpopts.IgnoreFields(module.Output{}, "Value"),
+		ctydebug.CmpOptions,
+	}
+	if diff := cmp.Diff(*expectedMeta, *meta, opts, ignoreDeclRanges); diff != "" {
+		t.Fatalf("module meta doesn't match: %s", diff)
+	}
+}
+
+func TestLoadModule_json(t *testing.T) {
+	path := t.TempDir()
+
+	cfg := `{
+  "terraform": {
+    "required_version": ">= 0.13",
+    "required_providers": {
+      "aws": {
+        "source": "hashicorp/aws",
+        "version": "~> 3.0"
+      },
+      "google": "~> 2.0"
+    }
+  },
+  "provider": {
+    "aws": [
+      {
+        "region": "us-east-1"
+      },
+      {
+        "alias": "west",
+        "region": "us-west-2"
+      }
+    ]
+  },
+  "resource": {
+    "aws_instance": {
+      "web": {
+        "provider": "aws.west",
+        "ami": "ami-123456"
+      }
+    }
+  },
+  "variable": {
+    "tags": {
+      "type": "map(string)"
+    }
+  },
+  "module": {
+    "vpc": {
+      "source": "terraform-aws-modules/vpc/aws",
+      "version": "3.1.0",
+      "name": "${var.name}"
+    }
+  }
+}`
+	f, diags := hcljson.Parse([]byte(cfg), "main.tf.json")
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	meta, diags := LoadModule(path, map[string]*hcl.File{
+		"main.tf.json": f,
+	})
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	awsProvider := tfaddr.Provider{
+		Hostname:  tfaddr.DefaultRegistryHost,
+		Namespace: "hashicorp",
+		Type:      "aws",
+	}
+	expectedMeta := &module.Meta{
+		Path:             path,
+		CoreRequirements: mustConstraints(t, ">= 0.13"),
+		RequiredCore:     []string{">= 0.13"},
+		ProviderReferences: map[module.ProviderRef]tfaddr.Provider{
+			{LocalName: "aws"}:                awsProvider,
+			{LocalName: "aws", Alias: "west"}: awsProvider,
+			{LocalName: "google"}:             tfaddr.NewLegacyProvider("google"),
+		},
+		ProviderRequirements: map[tfaddr.Provider]version.Constraints{
+			awsProvider:                        mustConstraints(t, "~> 3.0"),
+			tfaddr.NewLegacyProvider("google"): mustConstraints(t, "~> 2.0"),
+		},
+		RequiredProviders: map[string]*module.ProviderRequirement{
+			"aws": {
+				Source:             "hashicorp/aws",
+				VersionConstraints: []string{"~> 3.0"},
+			},
+			"google": {
+				VersionConstraints: []string{"~> 2.0"},
+			},
+		},
+		Resources: map[string]*module.Resource{
+			"aws_instance.web": {
+				Type:         "aws_instance",
+				Name:         "web",
+				Provider:     module.ProviderRef{LocalName: "aws", Alias: "west"},
+				ProviderAddr: awsProvider,
+			},
+		},
+		DataSources: map[string]*module.DataSource{},
+		ModuleSources: map[string]*module.ModuleSource{
+			"module.vpc": {
+				Name:    "vpc",
+				Source:  "terraform-aws-modules/vpc/aws",
+				Version: "3.1.0",
+			},
+		},
+		Variables: map[string]*module.Variable{
+			"tags": {
+				Name:       "tags",
+				Type:       cty.Map(cty.String),
+				IsNullable: true,
+			},
+		},
+		Outputs:      map[string]*module.Output{},
+		ProviderMeta: map[string]hcl.Body{},
+		Experiments:  []string{},
+		ProviderConfigs: []*module.ProviderConfig{
+			{LocalName: "aws"},
+			{LocalName: "aws", Alias: "west"},
+		},
+		EphemeralResources: map[string]*module.EphemeralResource{},
+		Checks:             map[string]*module.Check{},
+		Locals:             map[string]hcl.Expression{},
+		MovedBlocks:        []*module.Moved{},
+		Imports:            []*module.Import{},
+		Removed:            []*module.Removed{},
+	}
+
+	opts := cmp.Options{
+		cmp.Comparer(compareVersionConstraint),
+		cmpopts.IgnoreFields(module.Resource{}, "Body"),
+		cmpopts.IgnoreFields(module.DataSource{}, "Body"),
+		ctydebug.CmpOptions,
+	}
+	if diff := cmp.Diff(*expectedMeta, *meta, opts, ignoreDeclRanges); diff != "" {
+		t.Fatalf("module meta doesn't match: %s", diff)
+	}
+}
+
+func TestLoadModule_effectiveProviderConstraints(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+provider "aws" {
+  version = ">= 3.0"
+}
+`),
+		"versions.tf": mustParseFile(t, "versions.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "< 4.0"
+    }
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	constraints, diags := meta.RequiredProviders["aws"].EffectiveConstraints()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	v := version.Must(version.NewVersion("3.5.0"))
+	if !constraints.Check(v) {
+		t.Fatalf("expected %s to satisfy %s", v, constraints)
+	}
+	if len(constraints) != 2 {
+		t.Fatalf("expected 2 constraints, given: %s", constraints)
+	}
+	for _, unsatisfying := range []string{"2.9.0", "4.0.0"} {
+		v := version.Must(version.NewVersion(unsatisfying))
+		if constraints.Check(v) {
+			t.Fatalf("expected %s not to satisfy %s", v, constraints)
+		}
+	}
+}
+
+func TestLoadModule_metaJSONRoundTrip(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_version = ">= 0.13, < 2.0"
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 3.0"
+      configuration_aliases = [aws.east]
+    }
+  }
+}
+
+provider "google" {}
+
+variable "name" {
+  type        = string
+  description = "Name of the instance"
+  default     = "web"
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {
+    env = "dev"
+  }
+}
+
+variable "opt" {
+  default = null
+
+  validation {
+    condition     = var.opt != "invalid"
+    error_message = "Must not be invalid."
+  }
+}
+
+resource "aws_instance" "web" {
+  count      = 2
+  depends_on = [module.vpc, data.aws_ami.ubuntu]
+
+  provisioner "local-exec" {
+    when = destroy
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  provider = aws.east
+  for_each = toset(["a"])
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "3.0.0"
+  providers = {
+    aws = aws.east
+  }
+}
+
+output "ids" {
+  value      = aws_instance.web[*].id
+  sensitive  = true
+  depends_on = [aws_instance.web[0]]
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	b, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decodedMeta := &module.Meta{}
+	err = json.Unmarshal(b, decodedMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := cmp.Options{
+		cmp.Comparer(compareVersionConstraint),
+		cmp.Comparer(compareTraversal),
+		// expressions are restored as placeholders at the same range
+		cmp.Comparer(func(a, b hcl.Expression) bool {
+			if a == nil || b == nil {
+				return a == nil && b == nil
+			}
+			return a.Range() == b.Range()
+		}),
+		// lifecycle, dynamic blocks and bodies are not serialized
+		cmpopts.IgnoreFields(module.Resource{}, "Lifecycle", "DynamicBlocks", "Body"),
+		cmpopts.IgnoreFields(module.DataSource{}, "Lifecycle", "DynamicBlocks", "Body"),
+		ctydebug.CmpOptions,
+	}
+	if diff := cmp.Diff(*meta, *decodedMeta, opts); diff != "" {
+		t.Fatalf("round-tripped meta doesn't match: %s", diff)
+	}
+	if !meta.Equal(decodedMeta) {
+		t.Fatal("expected round-tripped meta to be equal")
+	}
+	if !decodedMeta.Resources["aws_instance.web"].HasCount() {
+		t.Fatal("expected round-tripped resource to have count")
+	}
+
+	// the output should be stable
+	b2, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(b), string(b2)); diff != "" {
+		t.Fatalf("JSON output is not stable: %s", diff)
+	}
+}
+
+func TestMetaUnmarshalJSON_unsupportedVersion(t *testing.T) {
+	err := json.Unmarshal([]byte(`{"format_version": 999}`), &module.Meta{})
+	if err == nil {
+		t.Fatal("expected error for unsupported format version")
+	}
+}
+
+func TestMetaUnmarshalJSON_incompatibleSnapshot(t *testing.T) {
+	payload := fmt.Sprintf(`{"format_version": %d, "schema_version": %d}`,
+		module.MetaFormatVersion, module.SchemaVersion+1)
+	err := json.Unmarshal([]byte(payload), &module.Meta{})
+
+	var snapshotErr *module.ErrIncompatibleSnapshot
+	if !errors.As(err, &snapshotErr) {
+		t.Fatalf("expected ErrIncompatibleSnapshot, given: %#v", err)
+	}
+	if snapshotErr.SchemaVersion != module.SchemaVersion+1 || snapshotErr.ExpectedVersion != module.SchemaVersion {
+		t.Fatalf("unexpected versions: %#v", snapshotErr)
+	}
+}
+
+func TestLoadModule_resourceProviderAddr(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_providers {
+    http = {
+      source = "example-corp/http"
+    }
+  }
+}
+
+resource "http_request" "test" {}
+
+resource "random_id" "test" {}
+
+data "http_request" "test" {
+  provider = http.undeclared
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	// the undeclared alias is reported, but still resolved
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Reference to undeclared provider configuration" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+
+	httpProvider := tfaddr.Provider{
+		Hostname:  tfaddr.DefaultRegistryHost,
+		Namespace: "example-corp",
+		Type:      "http",
+	}
+	expectedAddrs := map[string]tfaddr.Provider{
+		"http_request.test":      httpProvider,
+		"random_id.test":         tfaddr.NewLegacyProvider("random"),
+		"data.http_request.test": httpProvider,
+	}
+	givenAddrs := make(map[string]tfaddr.Provider, 0)
+	for key, r := range meta.Resources {
+		givenAddrs[key] = r.ProviderAddr
+	}
+	for key, ds := range meta.DataSources {
+		givenAddrs[key] = ds.ProviderAddr
+	}
+	if diff := cmp.Diff(expectedAddrs, givenAddrs); diff != "" {
+		t.Fatalf("provider addresses don't match: %s", diff)
+	}
+}
+
+func TestLoadModule_providerMeta(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_providers {
+    google = {
+      source = "hashicorp/google"
+    }
+  }
+
+  provider_meta "google" {
+    module_name = "blueprints/terraform/test/v0.0.1"
+  }
+
+  provider_meta "aws" {
+    module_name = "test"
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Severity != hcl.DiagWarning {
+		t.Fatalf("expected warning, given: %#v", diags[0].Severity)
+	}
+	if diags[0].Subject.Start.Line != 13 {
+		t.Fatalf("expected diagnostic to point to the aws provider_meta block, given: %s", diags[0].Subject)
+	}
+
+	body, ok := meta.ProviderMeta["google"]
+	if !ok {
+		t.Fatalf("expected google provider_meta, given: %#v", meta.ProviderMeta)
+	}
+	attrs, diags := body.JustAttributes()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if _, ok := attrs["module_name"]; !ok {
+		t.Fatalf("expected module_name attribute in provider_meta body")
+	}
+}
+
+func TestLoadModule_resourceBody(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+resource "aws_instance" "web" {
+  count = 2
+  ami   = "ami-123456"
+  tags = {
+    Name = "web"
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	r := meta.Resources["aws_instance.web"]
+	content, diags := r.Body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "ami"},
+			{Name: "tags"},
+		},
+	})
+	if len(diags) > 0 {
+		t.Fatalf("expected meta-arguments to be excluded from body: %s", diags)
+	}
+
+	tags, diags := content.Attributes["tags"].Expr.Value(nil)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	expectedTags := cty.ObjectVal(map[string]cty.Value{
+		"Name": cty.StringVal("web"),
+	})
+	if !tags.RawEquals(expectedTags) {
+		t.Fatalf("unexpected tags: %#v", tags)
+	}
+}
+
+func TestLoadModule_dataSourceBody(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+data "aws_ami" "ubuntu" {
+  provider    = aws
+  most_recent = true
+}
+
+check "health" {
+  data "http" "status" {
+    url = "https://example.com"
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	content, diags := meta.DataSources["data.aws_ami.ubuntu"].Body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "most_recent"},
+		},
+	})
+	if len(diags) > 0 {
+		t.Fatalf("expected meta-arguments to be excluded from body: %s", diags)
+	}
+	mostRecent, diags := content.Attributes["most_recent"].Expr.Value(nil)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if !mostRecent.RawEquals(cty.True) {
+		t.Fatalf("unexpected most_recent: %#v", mostRecent)
+	}
+
+	scoped := meta.Checks["health"].ScopedDataSources["data.http.status"]
+	if _, diags := scoped.Body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "url", Required: true},
+		},
+	}); len(diags) > 0 {
+		t.Fatal(diags)
+	}
+}
+
+func TestLoadModule_inferProviderFromDeclaredNames(t *testing.T) {
+	files := map[string]*hcl.File{
+		"providers.tf": mustParseFile(t, "providers.tf", `
+terraform {
+  required_providers {
+    google = {
+      source = "hashicorp/google"
+    }
+    google_beta = {
+      source = "hashicorp/google-beta"
+    }
+  }
+}
+`),
+		"main.tf": mustParseFile(t, "main.tf", `
+resource "google_beta_instance" "a" {}
+resource "google_compute_instance" "b" {}
+resource "google" "c" {}
+resource "aws_instance" "d" {}
+resource "google_beta_instance" "e" {
+  provider = google
+}
+data "google_beta_image" "f" {}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedNames := map[string]string{
+		"google_beta_instance.a":    "google_beta",
+		"google_compute_instance.b": "google",
+		"google.c":                  "google",
+		"aws_instance.d":            "aws",
+		"google_beta_instance.e":    "google",
+		"data.google_beta_image.f":  "google_beta",
+	}
+	names := make(map[string]string, 0)
+	for key, r := range meta.Resources {
+		names[key] = r.Provider.LocalName
+	}
+	for key, ds := range meta.DataSources {
+		names[key] = ds.Provider.LocalName
+	}
+	if diff := cmp.Diff(expectedNames, names); diff != "" {
+		t.Fatalf("unexpected provider names: %s", diff)
+	}
+
+	googleBeta := tfaddr.NewDefaultProvider("google-beta")
+	if given := meta.Resources["google_beta_instance.a"].ProviderAddr; given != googleBeta {
+		t.Fatalf("expected %s, given %s", googleBeta, given)
+	}
+}
+
+func TestInferProviderNameFromType(t *testing.T) {
+	testCases := []struct {
+		typeName     string
+		expectedName string
+	}{
+		{"aws_instance", "aws"},
+		{"azurerm_resource_group", "azurerm"},
+		{"azurerm_kubernetes_cluster_node_pool", "azurerm"},
+		{"null", "null"},
+		{"AzureRM_Resource_Group", "azurerm"},
+		{"_foo_bar", "foo"},
+		{"__foo", "foo"},
+		{"", ""},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.typeName), func(t *testing.T) {
+			name := inferProviderNameFromType(tc.typeName)
+			if name != tc.expectedName {
+				t.Fatalf("expected %q, given: %q", tc.expectedName, name)
+			}
+		})
+	}
+}
+
+func TestLoadModule_sensitiveLeaks(t *testing.T) {
+	files := map[string]*hcl.File{
+		"variables.tf": mustParseFile(t, "variables.tf", `
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+`),
+		"outputs.tf": mustParseFile(t, "outputs.tf", `
+output "db_password" {
+  value = var.db_password
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	leaks := module.FindSensitiveLeaks(meta)
+	if len(leaks) != 1 {
+		t.Fatalf("expected exactly 1 leak, %d given: %#v", len(leaks), leaks)
+	}
+	if leaks[0].Output != "db_password" || leaks[0].Variable != "db_password" {
+		t.Fatalf("unexpected leak: %#v", leaks[0])
+	}
+	if leaks[0].Range.Filename != "outputs.tf" {
+		t.Fatalf("expected leak to point to outputs.tf, given: %s", leaks[0].Range)
+	}
+}
+
+func TestLoadModule_referencedProviders(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      configuration_aliases = [aws.west]
+    }
+  }
+}
+
+provider "aws" {
+  region = "eu-west-1"
+}
+
+provider "aws" {
+  alias  = "east"
+  region = "us-east-1"
+}
+
+provider "google" {}
+
+resource "aws_instance" "web" {}
+
+resource "aws_instance" "db" {
+  provider = aws.west
+}
+
+data "aws_ami" "ubuntu" {
+  provider = aws.west
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedRefs := []module.ProviderRef{
+		{LocalName: "aws"},
+		{LocalName: "aws", Alias: "west"},
+	}
+	if diff := cmp.Diff(expectedRefs, meta.ReferencedProviders()); diff != "" {
+		t.Fatalf("referenced providers don't match: %s", diff)
+	}
+}
+
+func TestLoadModule_providerAliases(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+provider "aws" {
+  region = "eu-west-1"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+provider "aws" {
+  alias  = "east"
+  region = "us-east-1"
+}
+
+provider "google" {
+  alias = "europe"
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if diff := cmp.Diff([]string{"", "east", "west"}, meta.ProviderAliases("aws")); diff != "" {
+		t.Fatalf("aws aliases don't match: %s", diff)
+	}
+	// no default configuration
+	if diff := cmp.Diff([]string{"europe"}, meta.ProviderAliases("google")); diff != "" {
+		t.Fatalf("google aliases don't match: %s", diff)
+	}
+	if diff := cmp.Diff([]string{}, meta.ProviderAliases("azurerm")); diff != "" {
+		t.Fatalf("azurerm aliases don't match: %s", diff)
+	}
+}
+
+func TestLoadModule_moduleCalls(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+provider "aws" {
+  alias = "east"
+}
+
+module "network" {
+  source = "./modules/network"
+}
+
+module "consul" {
+  source  = "hashicorp/consul/aws"
+  version = "~> 0.11"
+
+  providers = {
+    aws = aws.east
+  }
+}
+
+module "storage" {
+  source = "git::https://example.com/storage.git?ref=v1.2.0"
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedCalls := []module.ModuleCall{
+		{
+			Name:    "consul",
+			Source:  "hashicorp/consul/aws",
+			Version: "~> 0.11",
+			Kind:    module.RegistryModuleSourceKind,
+			Providers: map[string]module.ProviderRef{
+				"aws": {LocalName: "aws", Alias: "east"},
+			},
+		},
+		{
+			Name:   "network",
+			Source: "./modules/network",
+			Kind:   module.LocalModuleSourceKind,
+		},
+		{
+			Name:   "storage",
+			Source: "git::https://example.com/storage.git?ref=v1.2.0",
+			Kind:   module.RemoteModuleSourceKind,
+		},
+	}
+	if diff := cmp.Diff(expectedCalls, meta.ModuleCalls()); diff != "" {
+		t.Fatalf("module calls don't match: %s", diff)
+	}
+}
+
+func TestLoadModule_diagnostics(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  experiments = [unknown_experiment]
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diff := cmp.Diff(diags, meta.Diagnostics); diff != "" {
+		t.Fatalf("diagnostics don't match: %s", diff)
+	}
+	if meta.HasErrors() {
+		t.Fatalf("expected warnings only, given: %s", meta.Diagnostics)
+	}
+}
+
+func TestLoadModule_filenames(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+provider "aws" {}
+
+resource "aws_instance" "web" {}
+
+data "aws_ami" "ubuntu" {}
+`),
+		"network.tf": mustParseFile(t, "network.tf", `
+provider "aws" {
+  alias = "east"
+}
+
+resource "aws_vpc" "main" {}
+
+module "subnets" {
+  source = "./subnets"
+}
+`),
+		"network_override.tf": mustParseFile(t, "network_override.tf", `
+resource "aws_vpc" "main" {
+  count = 2
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	filenames := make(map[string]string, 0)
+	for key, r := range meta.Resources {
+		filenames[key] = r.DeclRange.Filename
+	}
+	for key, ds := range meta.DataSources {
+		filenames[key] = ds.DeclRange.Filename
+	}
+	for key, ms := range meta.ModuleSources {
+		filenames[key] = ms.DeclRange.Filename
+	}
+	for _, cfg := range meta.ProviderConfigs {
+		filenames[fmt.Sprintf("provider.%s.%s", cfg.LocalName, cfg.Alias)] = cfg.DeclRange.Filename
+	}
+
+	expectedFilenames := map[string]string{
+		"aws_instance.web":    "main.tf",
+		"data.aws_ami.ubuntu": "main.tf",
+		"provider.aws.":       "main.tf",
+		"aws_vpc.main":        "network.tf",
+		"module.subnets":      "network.tf",
+		"provider.aws.east":   "network.tf",
+	}
+	if diff := cmp.Diff(expectedFilenames, filenames); diff != "" {
+		t.Fatalf("filenames don't match: %s", diff)
+	}
+}
+
+func TestLoadModule_overrideFiles(t *testing.T) {
+	path := t.TempDir()
+
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_version = ">= 0.12"
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 2.0"
+    }
+  }
+}
+`),
+		"override.tf": mustParseFile(t, "override.tf", `
+terraform {
+  required_version = ">= 0.15"
+}
+`),
+		"versions_override.tf": mustParseFile(t, "versions_override.tf", `
+terraform {
+  required_providers {
+    aws = {
+      version = "~> 3.0"
+    }
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(path, files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	awsProvider := tfaddr.Provider{
+		Hostname:  tfaddr.DefaultRegistryHost,
+		Namespace: "hashicorp",
+		Type:      "aws",
+	}
+	opts := cmp.Comparer(compareVersionConstraint)
+
+	expectedCore := mustConstraints(t, ">= 0.15")
+	if diff := cmp.Diff(expectedCore, meta.CoreRequirements, opts); diff != "" {
+		t.Fatalf("core requirements don't match: %s", diff)
+	}
+
+	expectedRequirements := map[tfaddr.Provider]version.Constraints{
+		awsProvider: mustConstraints(t, "~> 3.0"),
+	}
+	if diff := cmp.Diff(expectedRequirements, meta.ProviderRequirements, opts); diff != "" {
+		t.Fatalf("provider requirements don't match: %s", diff)
+	}
+}
+
+func TestLoadModule_overrideArguments(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+provider "aws" {
+  alias = "west"
+}
+
+variable "name" {
+  type     = string
+  default  = "web"
+  nullable = false
+}
+
+variable "tags" {
+  type      = map(string)
+  sensitive = true
+}
+
+resource "aws_instance" "web" {
+  count = 2
+
+  lifecycle {
+    create_before_destroy = true
+    prevent_destroy       = true
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  provider = aws.west
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+}
+
+output "id" {
+  description = "ID of the instance"
+  value       = aws_instance.web[0].id
+}
+`),
+		"main_override.tf": mustParseFile(t, "main_override.tf", `
+variable "name" {
+  default  = "api"
+  nullable = true
+}
+
+variable "tags" {
+  type = map(number)
+}
+
+resource "aws_instance" "web" {
+  provider   = aws.west
+  for_each   = toset(["a", "b"])
+  depends_on = [module.vpc]
+
+  lifecycle {
+    prevent_destroy = false
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  count = 1
+}
+
+module "vpc" {
+  providers = {
+    aws = aws.west
+  }
+}
+
+output "id" {
+  value     = aws_instance.web["a"].id
+  sensitive = true
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	name := meta.Variables["name"]
+	if !name.Type.Equals(cty.String) || !name.DefaultValue.RawEquals(cty.StringVal("api")) {
+		t.Fatalf("unexpected variable type or default: %s, %#v", name.Type.FriendlyName(), name.DefaultValue)
+	}
+	if !name.IsNullable {
+		t.Fatal("expected overridden nullable")
+	}
+	tags := meta.Variables["tags"]
+	if !tags.Type.Equals(cty.Map(cty.Number)) {
+		t.Fatalf("unexpected variable type: %s", tags.Type.FriendlyName())
+	}
+	if !tags.IsSensitive {
+		t.Fatal("expected base sensitive to be kept")
+	}
+
+	r := meta.Resources["aws_instance.web"]
+	if r.Provider != (module.ProviderRef{LocalName: "aws", Alias: "west"}) {
+		t.Fatalf("unexpected resource provider: %s", r.Provider)
+	}
+	if r.Count == nil || r.ForEach == nil {
+		t.Fatalf("expected both count and for_each to be kept, given: %#v, %#v", r.Count, r.ForEach)
+	}
+	if len(r.DependsOn) != 1 || traversalString(r.DependsOn[0]) != "module.vpc" {
+		t.Fatalf("unexpected overridden depends_on: %#v", r.DependsOn)
+	}
+	if !r.Lifecycle.CreateBeforeDestroy || r.Lifecycle.PreventDestroy {
+		t.Fatalf("unexpected merged lifecycle: %#v", r.Lifecycle)
+	}
+	if r.DeclRange.Filename != "main.tf" {
+		t.Fatalf("expected base range to be kept, given: %s", r.DeclRange)
+	}
+
+	ds := meta.DataSources["data.aws_ami.ubuntu"]
+	if ds.Provider != (module.ProviderRef{LocalName: "aws", Alias: "west"}) {
+		t.Fatalf("expected base provider to be kept, given: %s", ds.Provider)
+	}
+	if ds.Count == nil {
+		t.Fatal("expected overridden count")
+	}
+
+	ms := meta.ModuleSources["module.vpc"]
+	if ms.Source != "terraform-aws-modules/vpc/aws" || ms.Version != "5.1.0" {
+		t.Fatalf("expected base source and version to be kept, given: %q, %q", ms.Source, ms.Version)
+	}
+	expectedProviders := map[string]module.ProviderRef{
+		"aws": {LocalName: "aws", Alias: "west"},
+	}
+	if diff := cmp.Diff(expectedProviders, ms.Providers); diff != "" {
+		t.Fatalf("unexpected overridden providers: %s", diff)
+	}
+
+	o := meta.Outputs["id"]
+	if o.Description != "ID of the instance" {
+		t.Fatalf("expected base description to be kept, given: %q", o.Description)
+	}
+	if o.Value.Range().Filename != "main_override.tf" {
+		t.Fatalf("expected overridden value, given: %s", o.Value.Range())
+	}
+	if !o.IsSensitive {
+		t.Fatal("expected overridden sensitive")
+	}
+}
+
+func TestLoadModule_overrideSensitiveOutput(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+variable "token" {
+  sensitive = true
+}
+
+output "token" {
+  value = var.token
+}
+`),
+		"override.tf": mustParseFile(t, "override.tf", `
+output "token" {
+  sensitive = true
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if leaks := module.FindSensitiveLeaks(meta); len(leaks) > 0 {
+		t.Fatalf("expected no leaks once the output is marked sensitive, given: %#v", leaks)
+	}
+}
+
+func TestLoadModule_overrideMissingBase(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+resource "aws_instance" "web" {}
+`),
+		"override.tf": mustParseFile(t, "override.tf", `
+resource "aws_instance" "web" {
+  count = 2
+}
+
+resource "aws_subnet" "extra" {}
+
+variable "name" {}
+
+module "vpc" {
+  source = "./vpc"
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+
+	summaries := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		if diag.Subject.Filename != "override.tf" {
+			t.Fatalf("expected diagnostic for override.tf, given: %s", diag.Subject)
+		}
+		summaries = append(summaries, diag.Summary)
+	}
+	sort.Strings(summaries)
+	expectedSummaries := []string{
+		"Missing base module call for override",
+		"Missing base resource for override",
+		"Missing base variable for override",
+	}
+	if diff := cmp.Diff(expectedSummaries, summaries); diff != "" {
+		t.Fatalf("unexpected diagnostics: %s", diff)
+	}
+
+	expectResourceKeys(t, meta.Resources, []string{"aws_instance.web"})
+	if meta.Resources["aws_instance.web"].Count == nil {
+		t.Fatal("expected existing resource to be overridden")
+	}
+	if len(meta.Variables) != 0 || len(meta.ModuleSources) != 0 {
+		t.Fatalf("expected no variables or module calls, given: %#v, %#v", meta.Variables, meta.ModuleSources)
+	}
+}
+
+func BenchmarkLoadModule(b *testing.B) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(b, "main.tf", syntheticModuleConfig(2000)),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, diags := LoadModule("path", files)
+		if len(diags) > 0 {
+			b.Fatal(diags)
+		}
+	}
+}
+
+// syntheticModuleConfig returns configuration of a module
+// with the given number of resources, spread across a few providers
+func syntheticModuleConfig(resourceCount int) string {
+	providers := []string{"aws", "google", "azurerm", "null"}
+
+	var cfg strings.Builder
+	cfg.WriteString(`terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    google = {
+      source = "hashicorp/google"
+    }
+  }
+}
+
+variable "name" {
+  type = string
+}
+`)
+	for i := 0; i < resourceCount; i++ {
+		provider := providers[i%len(providers)]
+		fmt.Fprintf(&cfg, `
+resource "%s_thing" "r%d" {
+  count = 2
+  name  = "${var.name}-%d"
+  tags = {
+    index = %d
+  }
+  depends_on = [%s_thing.base]
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+`, provider, i, i, i, provider)
+	}
+
+	return cfg.String()
+}
+
+func TestLoadModule_refactoringBlocksAndLocals(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+locals {
+  name = "web"
+}
+
+moved {
+  from = aws_instance.old
+  to   = aws_instance.web
+}
+
+import {
+  to = aws_instance.web
+  id = "i-123456"
+}
+
+removed {
+  from = aws_instance.legacy
+}
+
+resource "aws_instance" "web" {}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	name, diags := meta.Locals["name"].Value(nil)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if !name.RawEquals(cty.StringVal("web")) {
+		t.Fatalf("unexpected local value: %#v", name)
+	}
+
+	if len(meta.MovedBlocks) != 1 || len(meta.Imports) != 1 || len(meta.Removed) != 1 {
+		t.Fatalf("expected 1 moved, import and removed block each, given: %d, %d, %d",
+			len(meta.MovedBlocks), len(meta.Imports), len(meta.Removed))
+	}
+	if given := traversalString(meta.Removed[0].From); given != "aws_instance.legacy" {
+		t.Fatalf("unexpected removed address: %q", given)
+	}
+}
@@ -0,0 +1,273 @@
+package earlydecoder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+// LoadOptions represents options for loading a module from a directory
+type LoadOptions struct {
+	// ValidateLocalModules enables warnings about local module
+	// sources which don't point at a directory with configuration files
+	ValidateLocalModules bool
+
+	// ReportUnsupportedBlocks enables diagnostics about top-level
+	// blocks which the early decoder doesn't decode, such as block
+	// types introduced in Terraform versions newer than this package.
+	//
+	// HCL has no informational severity, so these are reported
+	// as warnings with the "Unsupported block type" summary.
+	ReportUnsupportedBlocks bool
+}
+
+// LoadModuleFromDir reads, parses and decodes all configuration
+// files (*.tf and *.tf.json) of the module in the given directory.
+//
+// Hidden files, editor backup files and subdirectories are ignored.
+//
+// Failures to read the directory or any of the files are reported
+// as diagnostics and also returned as *module.FileReadError, such that
+// callers can tell these apart from invalid configuration. Only the first
+// such error is returned, while loading continues with other files.
+func LoadModuleFromDir(dir string) (*module.Meta, hcl.Diagnostics, error) {
+	return LoadModuleFromDirWithOptions(dir, LoadOptions{})
+}
+
+// LoadModuleFromDirWithOptions is like LoadModuleFromDir,
+// with additional validations enabled via the given options
+func LoadModuleFromDirWithOptions(dir string, opts LoadOptions) (*module.Meta, hcl.Diagnostics, error) {
+	return loadModuleFromDir(context.Background(), dir, opts)
+}
+
+// LoadModuleFromDirContext is like LoadModuleFromDir, but stops reading
+// further files once the given context is cancelled.
+//
+// On cancellation the module is decoded from files read so far,
+// a warning noting the truncation is reported and the context error
+// is returned, such that callers can discard the partial result.
+func LoadModuleFromDirContext(ctx context.Context, dir string) (*module.Meta, hcl.Diagnostics, error) {
+	return loadModuleFromDir(ctx, dir, LoadOptions{})
+}
+
+// utf8BOM is the UTF-8 encoded byte order mark
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// fileLoaded is called after each file is read and parsed,
+// which allows tests to act between files
+var fileLoaded = func(path string) {}
+
+func loadModuleFromDir(ctx context.Context, dir string, opts LoadOptions) (*module.Meta, hcl.Diagnostics, error) {
+	var diags hcl.Diagnostics
+	var readErr error
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read module directory",
+				Detail:   fmt.Sprintf("Module directory %s does not exist or cannot be read: %s", dir, err),
+			},
+		}, &module.FileReadError{Path: dir, Err: err}
+	}
+
+	parser := hclparse.NewParser()
+	files := make(map[string]*hcl.File, 0)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		if isIgnoredFile(name) {
+			continue
+		}
+
+		isJSON := strings.HasSuffix(name, ".tf.json")
+		if !isJSON && !strings.HasSuffix(name, ".tf") {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Module loading cancelled",
+				Detail:   fmt.Sprintf("Loading of module %s was cancelled after reading %d files, so the module is incomplete: %s", dir, len(files), err),
+			})
+			readErr = err
+			break
+		}
+
+		path := filepath.Join(dir, name)
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read file",
+				Detail:   fmt.Sprintf("The configuration file %q could not be read: %s", path, err),
+				Subject:  &hcl.Range{Filename: path},
+			})
+			if readErr == nil {
+				readErr = &module.FileReadError{Path: path, Err: err}
+			}
+			continue
+		}
+
+		// Editors on Windows may prefix files with a byte order mark,
+		// which HCL would reject as an invalid character. Only lines and
+		// columns of ranges stay accurate once it's stripped, byte offsets
+		// are lower than those in the file on disk by the length of the mark.
+		src = bytes.TrimPrefix(src, utf8BOM)
+
+		var (
+			f      *hcl.File
+			pDiags hcl.Diagnostics
+		)
+		if isJSON {
+			f, pDiags = parser.ParseJSON(src, path)
+		} else {
+			f, pDiags = parser.ParseHCL(src, path)
+		}
+		diags = append(diags, pDiags...)
+		if f != nil {
+			files[name] = f
+		}
+		fileLoaded(path)
+	}
+
+	meta, mDiags := LoadModule(dir, files)
+	diags = append(diags, mDiags...)
+
+	if opts.ReportUnsupportedBlocks {
+		diags = append(diags, unsupportedBlocks(files)...)
+	}
+
+	if opts.ValidateLocalModules {
+		diags = append(diags, validateLocalModuleSources(dir, meta)...)
+	}
+	meta.Diagnostics = diags
+
+	return meta, diags, readErr
+}
+
+// validateLocalModuleSources checks that local module sources resolve,
+// relative to the given directory, to directories containing
+// configuration files
+func validateLocalModuleSources(dir string, meta *module.Meta) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for _, mc := range meta.ModuleCalls() {
+		if mc.Kind != module.LocalModuleSourceKind {
+			continue
+		}
+		ms := meta.ModuleSources["module."+mc.Name]
+
+		modDir := filepath.Join(dir, filepath.FromSlash(mc.Source))
+		entries, err := ioutil.ReadDir(modDir)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Module directory not found",
+				Detail:   fmt.Sprintf("The source of module %q (%q) does not point at a readable directory: %s", mc.Name, mc.Source, err),
+				Subject:  ms.DeclRange.Ptr(),
+			})
+			continue
+		}
+
+		if !containsConfigFiles(entries) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Module directory contains no configuration files",
+				Detail:   fmt.Sprintf("The source of module %q (%q) points at a directory without any *.tf or *.tf.json files.", mc.Name, mc.Source),
+				Subject:  ms.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// unsupportedBlocks reports top-level blocks of the given files
+// which aren't part of the root schema and are therefore skipped
+func unsupportedBlocks(files map[string]*hcl.File) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	filenames := make([]string, 0, len(files))
+	for name := range files {
+		filenames = append(filenames, name)
+	}
+	sort.Strings(filenames)
+
+	supported := make(map[string]bool, len(rootSchema.Blocks))
+	for _, bs := range rootSchema.Blocks {
+		supported[bs.Type] = true
+	}
+
+	for _, name := range filenames {
+		f := files[name]
+
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			// In JSON any key which doesn't match the root schema
+			// is left behind in the body as an attribute
+			_, remain, _ := f.Body.PartialContent(rootSchema)
+			attrs, _ := remain.JustAttributes()
+			for blockType, attr := range attrs {
+				diags = append(diags, unsupportedBlockDiagnostic(blockType, attr.NameRange))
+			}
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			if supported[block.Type] {
+				continue
+			}
+			diags = append(diags, unsupportedBlockDiagnostic(block.Type, block.TypeRange))
+		}
+	}
+
+	return diags
+}
+
+func unsupportedBlockDiagnostic(blockType string, rng hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Unsupported block type",
+		Detail:   fmt.Sprintf("Blocks of type %q are not decoded and won't be reflected in the module metadata.", blockType),
+		Subject:  rng.Ptr(),
+	}
+}
+
+func containsConfigFiles(entries []os.FileInfo) bool {
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || isIgnoredFile(name) {
+			continue
+		}
+		if strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json") {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnoredFile returns true if the given filename
+// represents a file which Terraform ignores, such as
+// hidden files or editor backup files
+func isIgnoredFile(name string) bool {
+	return strings.HasPrefix(name, ".") || // Unix-like hidden files
+		strings.HasSuffix(name, "~") || // vim
+		strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#") // emacs
+}
@@ -0,0 +1,786 @@
+package earlydecoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-registry-address"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+func TestLoadModuleFromDir(t *testing.T) {
+	dir := filepath.Join("testdata", "dir-module")
+
+	meta, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if meta.Path != dir {
+		t.Fatalf("unexpected path: %q", meta.Path)
+	}
+
+	resources := make([]string, 0)
+	for key := range meta.Resources {
+		resources = append(resources, key)
+	}
+	sort.Strings(resources)
+	expectedResources := []string{"aws_instance.web"}
+	if diff := cmp.Diff(expectedResources, resources); diff != "" {
+		t.Fatalf("unexpected resources: %s", diff)
+	}
+
+	if _, ok := meta.Variables["region"]; !ok {
+		t.Fatalf("expected variable from JSON file, given: %#v", meta.Variables)
+	}
+
+	// override.tf is applied on top of main.tf
+	aws := tfaddr.Provider{
+		Hostname:  tfaddr.DefaultRegistryHost,
+		Namespace: "hashicorp",
+		Type:      "aws",
+	}
+	constraints := meta.ProviderRequirements[aws]
+	if constraints.String() != "~> 3.50" {
+		t.Fatalf("expected overridden constraint, given: %q", constraints.String())
+	}
+}
+
+func TestLoadModuleFromDir_missingDir(t *testing.T) {
+	_, diags, err := LoadModuleFromDir(filepath.Join("testdata", "missing"))
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Severity != hcl.DiagError {
+		t.Fatalf("expected error, given: %#v", diags[0].Severity)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, given: %#v", err)
+	}
+}
+
+func TestLoadModuleFromDir_fileGone(t *testing.T) {
+	dir := t.TempDir()
+
+	err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(`
+resource "aws_instance" "web" {
+}
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a dangling symlink is listed in the directory but cannot be read,
+	// just like a file removed after the directory was listed
+	gonePath := filepath.Join(dir, "gone.tf")
+	err = os.Symlink(filepath.Join(dir, "missing.tf"), gonePath)
+	if err != nil {
+		t.Skipf("unable to create symlink: %s", err)
+	}
+
+	meta, diags, err := LoadModuleFromDir(dir)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+
+	var readErr *module.FileReadError
+	if !errors.As(err, &readErr) {
+		t.Fatalf("expected FileReadError, given: %#v", err)
+	}
+	if readErr.Path != gonePath {
+		t.Fatalf("unexpected path: %q", readErr.Path)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, given: %#v", readErr.Err)
+	}
+
+	// other files are still decoded
+	if _, ok := meta.Resources["aws_instance.web"]; !ok {
+		t.Fatalf("expected resource from readable file, given: %#v", meta.Resources)
+	}
+}
+
+func TestIsIgnoredFile(t *testing.T) {
+	testCases := map[string]bool{
+		"main.tf":     false,
+		".hidden.tf":  true,
+		"main.tf~":    true,
+		"#main.tf#":   true,
+		"#main.tf":    false,
+		"override.tf": false,
+	}
+
+	for name, expected := range testCases {
+		if given := isIgnoredFile(name); given != expected {
+			t.Errorf("%q: expected %t, given %t", name, expected, given)
+		}
+	}
+}
+
+func TestLoadModuleFromDirWithOptions_validateLocalModules(t *testing.T) {
+	dir := filepath.Join("testdata", "local-modules")
+
+	_, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatalf("expected no diagnostics without validation, given: %s", diags)
+	}
+
+	_, diags, err = LoadModuleFromDirWithOptions(dir, LoadOptions{
+		ValidateLocalModules: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summaries := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagWarning {
+			t.Fatalf("expected warning, given: %s", diag)
+		}
+		summaries = append(summaries, diag.Summary)
+	}
+	expectedSummaries := []string{
+		"Module directory contains no configuration files",
+		"Module directory not found",
+	}
+	if diff := cmp.Diff(expectedSummaries, summaries); diff != "" {
+		t.Fatalf("unexpected diagnostics: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDirWithOptions_reportUnsupportedBlocks(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+}
+
+stack "prod" {
+  source = "./stack"
+}
+`,
+		"main.tf.json": `{
+  "variable": {
+    "name": {}
+  },
+  "deployment": {
+    "prod": {}
+  }
+}`,
+	}
+	for name, src := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatalf("expected no diagnostics by default, given: %s", diags)
+	}
+
+	_, diags, err = LoadModuleFromDirWithOptions(dir, LoadOptions{
+		ReportUnsupportedBlocks: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	details := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagWarning || diag.Summary != "Unsupported block type" {
+			t.Fatalf("unexpected diagnostic: %s", diag)
+		}
+		details = append(details, diag.Detail)
+	}
+	expectedDetails := []string{
+		`Blocks of type "stack" are not decoded and won't be reflected in the module metadata.`,
+		`Blocks of type "deployment" are not decoded and won't be reflected in the module metadata.`,
+	}
+	if diff := cmp.Diff(expectedDetails, details); diff != "" {
+		t.Fatalf("unexpected diagnostics: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_unknownProviderAlias(t *testing.T) {
+	dir := filepath.Join("testdata", "unknown-provider-alias")
+
+	meta, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Severity != hcl.DiagWarning || diags[0].Summary != "Unknown provider alias" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+
+	expectedConfigs := []*module.ProviderConfig{
+		{LocalName: "aws"},
+		{LocalName: "aws", AliasUnknown: true},
+	}
+	if diff := cmp.Diff(expectedConfigs, meta.ProviderConfigs, ignoreDeclRanges); diff != "" {
+		t.Fatalf("unexpected provider configs: %s", diff)
+	}
+
+	if diff := cmp.Diff([]string{""}, meta.ProviderAliases("aws")); diff != "" {
+		t.Fatalf("unexpected aliases: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDirContext_cancelled(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"a.tf", "b.tf", "c.tf"} {
+		src := fmt.Sprintf("resource \"aws_instance\" %q {\n}\n", strings.TrimSuffix(name, ".tf"))
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	origFileLoaded := fileLoaded
+	defer func() {
+		fileLoaded = origFileLoaded
+	}()
+	fileLoaded = func(path string) {
+		cancel()
+	}
+
+	meta, diags, err := LoadModuleFromDirContext(ctx, dir)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled error, given: %#v", err)
+	}
+
+	if len(diags) != 1 || diags[0].Summary != "Module loading cancelled" {
+		t.Fatalf("expected cancellation diagnostic, given: %s", diags)
+	}
+
+	expectedResources := []string{"aws_instance.a"}
+	resources := make([]string, 0, len(meta.Resources))
+	for key := range meta.Resources {
+		resources = append(resources, key)
+	}
+	if diff := cmp.Diff(expectedResources, resources); diff != "" {
+		t.Fatalf("unexpected partial result: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_providerMeta(t *testing.T) {
+	dir := filepath.Join("testdata", "provider-meta")
+
+	meta, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Misplaced provider_meta block" || diags[0].Subject.Start.Line != 13 {
+		t.Fatalf("expected diagnostic for the top-level provider_meta block, given: %s", diags[0])
+	}
+
+	body, ok := meta.ProviderMeta["google"]
+	if !ok {
+		t.Fatalf("expected google provider_meta, given: %#v", meta.ProviderMeta)
+	}
+	attrs, diags := body.JustAttributes()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if attrs["module_name"].Range.Start.Line != 9 {
+		t.Fatalf("expected the nested provider_meta body, given: %#v", attrs["module_name"].Range)
+	}
+
+	expectedConfigs := []*module.ProviderConfig{
+		{LocalName: "google"},
+	}
+	if diff := cmp.Diff(expectedConfigs, meta.ProviderConfigs, ignoreDeclRanges); diff != "" {
+		t.Fatalf("unexpected provider configs: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_providerNestedBlocks(t *testing.T) {
+	dir := filepath.Join("testdata", "provider-nested-blocks")
+
+	meta, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedConfigs := []*module.ProviderConfig{
+		{
+			LocalName:        "aws",
+			NestedBlockTypes: []string{"assume_role", "default_tags"},
+		},
+		{
+			LocalName: "aws",
+			Alias:     "west",
+		},
+	}
+	if diff := cmp.Diff(expectedConfigs, meta.ProviderConfigs, ignoreDeclRanges); diff != "" {
+		t.Fatalf("unexpected provider configs: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_requiredVersionList(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "required-version-list"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if constraints := meta.CoreRequirements.String(); constraints != ">= 1.0,< 2.0" {
+		t.Fatalf("unexpected core requirements: %q", constraints)
+	}
+}
+
+func TestLoadModuleFromDir_requiredVersionObject(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "required-version-object"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	expectedDetail := "The required_version argument must be a version constraint string, or a list of such strings."
+	if diags[0].Summary != "Invalid required_version" || diags[0].Detail != expectedDetail {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+
+	if len(meta.CoreRequirements) != 0 {
+		t.Fatalf("expected no core requirements, given: %q", meta.CoreRequirements)
+	}
+}
+
+func TestLoadModuleFromDir_requiredVersionHeredoc(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "required-version-heredoc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if diff := cmp.Diff([]string{">= 1.5, < 2.0"}, meta.RequiredCore); diff != "" {
+		t.Fatalf("unexpected core requirements: %s", diff)
+	}
+	if len(meta.CoreRequirements) != 2 {
+		t.Fatalf("expected 2 core constraints, given: %q", meta.CoreRequirements)
+	}
+}
+
+func TestLoadModule_requiredVersionReference(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_version = ">= ${var.min_version}"
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Severity != hcl.DiagWarning || diags[0].Summary != "Non-constant required_version" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+	if meta.RequiredCore != nil {
+		t.Fatalf("expected no core requirements, given: %q", meta.RequiredCore)
+	}
+}
+
+func TestLoadModuleFromDir_variableValidations(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "variable-validations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if validations := meta.Variables["name"].Validations; validations != nil {
+		t.Fatalf("expected no validations, given: %#v", validations)
+	}
+
+	validations := meta.Variables["image_id"].Validations
+	if len(validations) != 2 {
+		t.Fatalf("expected 2 validations, %d given", len(validations))
+	}
+
+	for i, rule := range validations {
+		refs := make([]string, 0)
+		for _, traversal := range rule.Condition.Variables() {
+			ref := traversal.RootName()
+			if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+				ref += "." + attr.Name
+			}
+			refs = append(refs, ref)
+		}
+		if diff := cmp.Diff([]string{"var.image_id"}, refs); diff != "" {
+			t.Fatalf("validation %d: unexpected condition references: %s", i, diff)
+		}
+	}
+
+	msg, diags := validations[1].ErrorMessage.Value(nil)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if msg.AsString() != `The image_id value must start with "ami-".` {
+		t.Fatalf("unexpected error message: %q", msg.AsString())
+	}
+}
+
+func TestLoadModuleFromDir_provisioners(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "provisioners"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	web := meta.Resources["aws_instance.web"]
+	if !web.HasConnection {
+		t.Fatal("expected resource-level connection block")
+	}
+
+	expectedProvisioners := []*module.Provisioner{
+		{
+			Type: "local-exec",
+			DeclRange: hcl.Range{
+				Filename: filepath.Join("testdata", "provisioners", "main.tf"),
+				Start:    hcl.Pos{Line: 10, Column: 3, Byte: 137},
+				End:      hcl.Pos{Line: 10, Column: 27, Byte: 161},
+			},
+		},
+		{
+			Type:          "remote-exec",
+			WhenDestroy:   true,
+			HasConnection: true,
+			DeclRange: hcl.Range{
+				Filename: filepath.Join("testdata", "provisioners", "main.tf"),
+				Start:    hcl.Pos{Line: 14, Column: 3, Byte: 230},
+				End:      hcl.Pos{Line: 14, Column: 28, Byte: 255},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedProvisioners, web.Provisioners); diff != "" {
+		t.Fatalf("unexpected provisioners: %s", diff)
+	}
+
+	db := meta.Resources["aws_instance.db"]
+	if db.Provisioners != nil || db.HasConnection {
+		t.Fatalf("expected no provisioners, given: %#v", db.Provisioners)
+	}
+}
+
+func TestLoadModuleFromDir_bomAndCRLF(t *testing.T) {
+	dir := filepath.Join("testdata", "bom-crlf")
+
+	meta, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	filename := filepath.Join(dir, "main.tf")
+	expectedRanges := map[string]hcl.Range{
+		"var.name": {
+			Filename: filename,
+			Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+			End:      hcl.Pos{Line: 1, Column: 16, Byte: 15},
+		},
+		"aws_instance.web": {
+			Filename: filename,
+			Start:    hcl.Pos{Line: 5, Column: 1, Byte: 41},
+			End:      hcl.Pos{Line: 5, Column: 30, Byte: 70},
+		},
+	}
+	givenRanges := map[string]hcl.Range{
+		"var.name":         meta.Variables["name"].DeclRange,
+		"aws_instance.web": meta.Resources["aws_instance.web"].DeclRange,
+	}
+	if diff := cmp.Diff(expectedRanges, givenRanges); diff != "" {
+		t.Fatalf("unexpected ranges: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_ephemeralResources(t *testing.T) {
+	dir := filepath.Join("testdata", "ephemeral-resources")
+
+	meta, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if _, ok := meta.Resources["aws_secretsmanager_secret_version.db"]; !ok || len(meta.Resources) != 1 {
+		t.Fatalf("expected a single managed resource, given: %#v", meta.Resources)
+	}
+
+	er, ok := meta.EphemeralResources["ephemeral.aws_secretsmanager_secret_version.db"]
+	if !ok || len(meta.EphemeralResources) != 1 {
+		t.Fatalf("expected a single ephemeral resource, given: %#v", meta.EphemeralResources)
+	}
+	if !er.HasCount() || er.HasForEach() {
+		t.Fatalf("expected count only, given count: %#v, for_each: %#v", er.Count, er.ForEach)
+	}
+
+	expectedProvider := module.ProviderRef{LocalName: "aws"}
+	if er.Provider != expectedProvider {
+		t.Fatalf("unexpected provider: %#v", er.Provider)
+	}
+	expectedAddr := tfaddr.NewDefaultProvider("aws")
+	if er.ProviderAddr != expectedAddr {
+		t.Fatalf("unexpected provider address: %s", er.ProviderAddr)
+	}
+
+	expectedRange := hcl.Range{
+		Filename: filepath.Join(dir, "main.tf"),
+		Start:    hcl.Pos{Line: 13, Column: 1, Byte: 174},
+		End:      hcl.Pos{Line: 13, Column: 51, Byte: 224},
+	}
+	if diff := cmp.Diff(expectedRange, er.DeclRange); diff != "" {
+		t.Fatalf("unexpected range: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_ephemeralVariables(t *testing.T) {
+	dir := filepath.Join("testdata", "ephemeral-variables")
+
+	meta, diags, err := LoadModuleFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if !meta.Variables["token"].Ephemeral || meta.Variables["region"].Ephemeral {
+		t.Fatal("expected only var.token to be ephemeral")
+	}
+	if !meta.Outputs["token"].Ephemeral || meta.Outputs["endpoint"].Ephemeral {
+		t.Fatal("expected only output.token to be ephemeral")
+	}
+
+	diags = module.ValidateEphemeralOutputs(meta)
+	expectedDiags := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagWarning,
+			Summary:  "Ephemeral value in non-ephemeral output",
+			Detail: `Output "endpoint" refers to ephemeral variable "token", but is not declared as ephemeral. ` +
+				`Ephemeral values are not persisted and cannot be used in regular outputs.`,
+			Subject: &hcl.Range{
+				Filename: filepath.Join(dir, "main.tf"),
+				Start:    hcl.Pos{Line: 16, Column: 55, Byte: 238},
+				End:      hcl.Pos{Line: 16, Column: 64, Byte: 247},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedDiags, diags); diff != "" {
+		t.Fatalf("unexpected diagnostics: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_checkScopedProvider(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "check-scoped-provider"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if len(meta.DataSources) != 0 {
+		t.Fatalf("expected no top-level data sources, given: %#v", meta.DataSources)
+	}
+
+	check, ok := meta.Checks["bucket_exists"]
+	if !ok {
+		t.Fatalf("expected check to be decoded, given: %#v", meta.Checks)
+	}
+	ds, ok := check.ScopedDataSources["data.aws_s3_bucket.logs"]
+	if !ok {
+		t.Fatalf("expected scoped data source, given: %#v", check.ScopedDataSources)
+	}
+
+	expectedProvider := module.ProviderRef{LocalName: "aws", Alias: "west"}
+	if ds.Provider != expectedProvider {
+		t.Fatalf("unexpected provider: %#v", ds.Provider)
+	}
+	expectedAddr := tfaddr.NewDefaultProvider("aws")
+	if ds.ProviderAddr != expectedAddr {
+		t.Fatalf("unexpected provider address: %s", ds.ProviderAddr)
+	}
+}
+
+func TestLoadModuleFromDir_multipleTerraformBlocks(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "multiple-terraform-blocks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if meta.Backend == nil || meta.Backend.Type != "s3" {
+		t.Fatalf("expected s3 backend, given: %#v", meta.Backend)
+	}
+	if meta.Cloud != nil {
+		t.Fatalf("expected no cloud block, given: %#v", meta.Cloud)
+	}
+
+	expectedCore := []string{">= 1.5", "< 2.0"}
+	if diff := cmp.Diff(expectedCore, meta.RequiredCore); diff != "" {
+		t.Fatalf("unexpected core requirements: %s", diff)
+	}
+	if constraints := meta.CoreRequirements.String(); constraints != ">= 1.5,< 2.0" {
+		t.Fatalf("unexpected core constraints: %q", constraints)
+	}
+
+	aws := tfaddr.NewDefaultProvider("aws")
+	if constraints := meta.ProviderRequirements[aws].String(); constraints != "~> 5.0" {
+		t.Fatalf("unexpected provider constraints: %q", constraints)
+	}
+}
+
+func TestLoadModule_backendsAcrossFiles(t *testing.T) {
+	files := map[string]*hcl.File{
+		"a.tf": mustParseFile(t, "a.tf", `
+terraform {
+  backend "s3" {}
+}
+`),
+		"b.tf": mustParseFile(t, "b.tf", `
+terraform {
+  required_version = ">= 1.0"
+}
+
+terraform {
+  backend "gcs" {}
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Multiple backend definitions" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if diags[0].Subject.Filename != "b.tf" {
+		t.Fatalf("expected diagnostic for b.tf, given: %s", diags[0].Subject)
+	}
+	if meta.Backend.Type != "s3" {
+		t.Fatalf("expected first backend to be kept, given: %q", meta.Backend.Type)
+	}
+	if diff := cmp.Diff([]string{">= 1.0"}, meta.RequiredCore); diff != "" {
+		t.Fatalf("unexpected core requirements: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_syntaxError(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "syntax-error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diags) != 1 || diags[0].Summary != "Invalid expression" {
+		t.Fatalf("expected only the parse error, given: %s", diags)
+	}
+	if diff := cmp.Diff(diags, meta.Diagnostics); diff != "" {
+		t.Fatalf("expected parse error to be included in meta: %s", diff)
+	}
+
+	if _, ok := meta.Resources["aws_instance.web"]; !ok {
+		t.Fatalf("expected valid resource to be decoded, given: %#v", meta.Resources)
+	}
+	if _, ok := meta.Variables["name"]; !ok {
+		t.Fatalf("expected variable after the malformed block to be decoded, given: %#v", meta.Variables)
+	}
+}
+
+func TestLoadModule_nilFile(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `resource "aws_instance" "web" {}`),
+		"bad.tf":  nil,
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if len(meta.Resources) != 1 {
+		t.Fatalf("expected 1 resource, given: %#v", meta.Resources)
+	}
+}
+
+func TestLoadModuleFromDir_inferredProviders(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "inferred-providers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedProviders := []module.ModuleProvider{
+		{
+			LocalName: "aws",
+			Addr:      tfaddr.NewDefaultProvider("aws"),
+			Required:  true,
+		},
+		{
+			LocalName: "google",
+			Addr:      tfaddr.NewLegacyProvider("google"),
+			Required:  false,
+		},
+		{
+			LocalName: "random",
+			Addr:      tfaddr.NewLegacyProvider("random"),
+			Required:  false,
+		},
+	}
+	if diff := cmp.Diff(expectedProviders, meta.Providers()); diff != "" {
+		t.Fatalf("unexpected providers: %s", diff)
+	}
+}
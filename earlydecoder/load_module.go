@@ -0,0 +1,1374 @@
+package earlydecoder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-schema/module"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// decodedModule is the type representing a decoded Terraform module.
+type decodedModule struct {
+	RequiredCore         []string
+	Experiments          []string
+	ProviderRequirements map[string]*providerRequirement
+	ProviderConfigs      map[string]*providerConfig
+	Resources            map[string]*module.Resource
+	DataSources          map[string]*module.DataSource
+	EphemeralResources   map[string]*module.EphemeralResource
+	ModuleSources        map[string]*module.ModuleSource
+	Variables            map[string]*module.Variable
+	Outputs              map[string]*module.Output
+	Locals               map[string]*hcl.Attribute
+	Backend              *module.Backend
+	Cloud                *module.CloudConfig
+	ProviderMetas        map[string]*providerMeta
+	MovedBlocks          []*module.Moved
+	Imports              []*module.Import
+	Removed              []*module.Removed
+	Checks               map[string]*module.Check
+
+	// ProviderAttrRanges contains ranges of the provider attribute
+	// of resources and data sources, keyed by their map keys.
+	// Data sources scoped to check blocks are keyed by checkScopedKey.
+	ProviderAttrRanges map[string]hcl.Range
+
+	// DeclaredAttributes contains attributes declared in the bodies of
+	// resources, data sources, ephemeral resources, module calls,
+	// variables and outputs, keyed by their map keys, such that
+	// override files only replace arguments which they declare.
+	// LifecycleAttributes contains those of their lifecycle blocks.
+	// Both are only populated for modules decoded from a single file.
+	DeclaredAttributes  map[string]hcl.Attributes
+	LifecycleAttributes map[string]hcl.Attributes
+
+	// BackendRange and CloudRange are ranges of the backend
+	// and cloud blocks respectively, if either is declared
+	BackendRange hcl.Range
+	CloudRange   hcl.Range
+}
+
+func newDecodedModule() *decodedModule {
+	return newDecodedModuleWithCapacity(nil)
+}
+
+// newDecodedModuleWithCapacity returns a module with maps pre-sized
+// according to the given number of blocks of each type, such that
+// large modules don't need to grow the maps repeatedly while decoding
+func newDecodedModuleWithCapacity(blockCounts map[string]int) *decodedModule {
+	return &decodedModule{
+		RequiredCore:         make([]string, 0),
+		Experiments:          make([]string, 0),
+		ProviderRequirements: make(map[string]*providerRequirement, blockCounts["provider"]),
+		ProviderConfigs:      make(map[string]*providerConfig, blockCounts["provider"]),
+		Resources:            make(map[string]*module.Resource, blockCounts["resource"]),
+		DataSources:          make(map[string]*module.DataSource, blockCounts["data"]),
+		EphemeralResources:   make(map[string]*module.EphemeralResource, blockCounts["ephemeral"]),
+		ModuleSources:        make(map[string]*module.ModuleSource, blockCounts["module"]),
+		Variables:            make(map[string]*module.Variable, blockCounts["variable"]),
+		Outputs:              make(map[string]*module.Output, blockCounts["output"]),
+		Locals:               make(map[string]*hcl.Attribute, 0),
+		ProviderMetas:        make(map[string]*providerMeta, 0),
+		MovedBlocks:          make([]*module.Moved, 0, blockCounts["moved"]),
+		Imports:              make([]*module.Import, 0, blockCounts["import"]),
+		Removed:              make([]*module.Removed, 0, blockCounts["removed"]),
+		Checks:               make(map[string]*module.Check, blockCounts["check"]),
+		ProviderAttrRanges:   make(map[string]hcl.Range, 0),
+		DeclaredAttributes:   make(map[string]hcl.Attributes, 0),
+		LifecycleAttributes:  make(map[string]hcl.Attributes, 0),
+	}
+}
+
+// countBlocks returns the number of top-level blocks of each type
+// in the given file, or nil if the body doesn't allow counting
+// them without decoding, which is the case for JSON
+func countBlocks(file *hcl.File) map[string]int {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+
+	counts := make(map[string]int, 0)
+	for _, block := range body.Blocks {
+		counts[block.Type]++
+	}
+	return counts
+}
+
+// providerConfig represents a provider block in the configuration
+type providerConfig struct {
+	Name  string
+	Alias string
+
+	// AliasUnknown is true if the alias is not a literal string
+	AliasUnknown bool
+
+	NestedBlockTypes []string
+
+	DeclRange hcl.Range
+}
+
+// providerMeta represents a provider_meta block
+// within the terraform block
+type providerMeta struct {
+	Name      string
+	Body      hcl.Body
+	DeclRange hcl.Range
+}
+
+// loadModuleFromFile reads given file, interprets it and stores in given module
+func loadModuleFromFile(file *hcl.File, mod *decodedModule) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	content, _, contentDiags := file.Body.PartialContent(rootSchema)
+	diags = append(diags, contentDiags...)
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+
+		case "terraform":
+			content, _, contentDiags := block.Body.PartialContent(terraformBlockSchema)
+			diags = append(diags, contentDiags...)
+
+			if attr, defined := content.Attributes["required_version"]; defined {
+				constraints, rvDiags := decodeRequiredVersion(attr)
+				diags = append(diags, rvDiags...)
+				mod.RequiredCore = append(mod.RequiredCore, constraints...)
+			}
+
+			if attr, defined := content.Attributes["experiments"]; defined {
+				experiments, expDiags := decodeExperiments(attr)
+				diags = append(diags, expDiags...)
+				mod.Experiments = append(mod.Experiments, experiments...)
+			}
+
+			// HCL permits required_providers to be declared as an attribute
+			// too, which is only distinguishable in the native syntax
+			if body, ok := block.Body.(*hclsyntax.Body); ok {
+				if attr, defined := body.Attributes["required_providers"]; defined {
+					reqs, reqsDiags := decodeRequiredProvidersAttribute(attr.AsHCLAttribute())
+					diags = append(diags, reqsDiags...)
+					diags = append(diags, addProviderRequirements(mod, reqs, attr.NameRange)...)
+				}
+			}
+
+			for _, innerBlock := range content.Blocks {
+				switch innerBlock.Type {
+				case "required_providers":
+					reqs, reqsDiags := decodeRequiredProvidersBlock(innerBlock)
+					diags = append(diags, reqsDiags...)
+					diags = append(diags, addProviderRequirements(mod, reqs, innerBlock.DefRange)...)
+				case "backend":
+					if mod.Backend != nil {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Multiple backend definitions",
+							Detail:   fmt.Sprintf("Found multiple backend definitions: %q, %q", mod.Backend.Type, innerBlock.Labels[0]),
+							Subject:  &innerBlock.DefRange,
+						})
+						continue
+					}
+					if mod.Cloud != nil {
+						diags = append(diags, cloudBackendConflictDiagnostic(innerBlock.DefRange))
+						continue
+					}
+					mod.Backend = &module.Backend{
+						Type:   innerBlock.Labels[0],
+						Config: innerBlock.Body,
+					}
+					mod.BackendRange = innerBlock.DefRange
+				case "cloud":
+					if mod.Cloud != nil {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Multiple cloud definitions",
+							Detail:   "Found multiple cloud blocks, only one is allowed",
+							Subject:  &innerBlock.DefRange,
+						})
+						continue
+					}
+					if mod.Backend != nil {
+						diags = append(diags, cloudBackendConflictDiagnostic(innerBlock.DefRange))
+						continue
+					}
+					cloud, cDiags := decodeCloudBlock(innerBlock)
+					diags = append(diags, cDiags...)
+					mod.Cloud = cloud
+					mod.CloudRange = innerBlock.DefRange
+				case "provider_meta":
+					name := innerBlock.Labels[0]
+					if _, exists := mod.ProviderMetas[name]; exists {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Multiple provider_meta definitions",
+							Detail:   fmt.Sprintf("Found multiple provider_meta blocks for provider %q", name),
+							Subject:  &innerBlock.DefRange,
+						})
+						continue
+					}
+					mod.ProviderMetas[name] = &providerMeta{
+						Name:      name,
+						Body:      innerBlock.Body,
+						DeclRange: innerBlock.DefRange,
+					}
+				}
+			}
+
+		case "variable":
+			content, _, contentDiags := block.Body.PartialContent(variableSchema)
+			diags = append(diags, contentDiags...)
+
+			name := block.Labels[0]
+			v := &module.Variable{
+				Name:       name,
+				Type:       cty.DynamicPseudoType,
+				IsNullable: true,
+				DeclRange:  block.DefRange,
+			}
+
+			mod.Variables[name] = v
+			mod.DeclaredAttributes[v.MapKey()] = content.Attributes
+
+			if attr, defined := content.Attributes["type"]; defined {
+				// Terraform may evolve its type expression syntax in future
+				// versions, so we don't want to be overly-strict here and
+				// fall back to an unknown type instead of raising errors.
+				v.Type = decodeVariableType(attr.Expr)
+			}
+
+			if attr, defined := content.Attributes["description"]; defined {
+				var description string
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &description)
+				diags = append(diags, valDiags...)
+				v.Description = description
+			}
+
+			if attr, defined := content.Attributes["default"]; defined {
+				val, valDiags := attr.Expr.Value(nil)
+				diags = append(diags, valDiags...)
+				if !valDiags.HasErrors() {
+					v.DefaultValue = val
+				}
+			}
+
+			if attr, defined := content.Attributes["sensitive"]; defined {
+				var sensitive bool
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sensitive)
+				diags = append(diags, valDiags...)
+				v.IsSensitive = sensitive
+			}
+
+			if attr, defined := content.Attributes["ephemeral"]; defined {
+				var ephemeral bool
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ephemeral)
+				diags = append(diags, valDiags...)
+				v.Ephemeral = ephemeral
+			}
+
+			if attr, defined := content.Attributes["nullable"]; defined {
+				var nullable bool
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &nullable)
+				diags = append(diags, valDiags...)
+				if !valDiags.HasErrors() {
+					v.IsNullable = nullable
+				}
+			}
+
+			for _, innerBlock := range content.Blocks {
+				if innerBlock.Type == "validation" {
+					rule, ruleDiags := decodeCheckRule(innerBlock)
+					diags = append(diags, ruleDiags...)
+					v.Validations = append(v.Validations, rule)
+				}
+			}
+
+		case "locals":
+			attrs, attrDiags := block.Body.JustAttributes()
+			diags = append(diags, attrDiags...)
+
+			for name, attr := range attrs {
+				if _, exists := mod.Locals[name]; exists {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Duplicate local value definition",
+						Detail:   fmt.Sprintf("Found multiple definitions of local value %q", name),
+						Subject:  &attr.NameRange,
+					})
+					continue
+				}
+				mod.Locals[name] = attr
+			}
+
+		case "output":
+			content, _, contentDiags := block.Body.PartialContent(outputSchema)
+			diags = append(diags, contentDiags...)
+
+			name := block.Labels[0]
+			o := &module.Output{
+				Name:      name,
+				DeclRange: block.DefRange,
+			}
+
+			if _, exists := mod.Outputs[name]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple output definitions",
+					Detail:   fmt.Sprintf("Found multiple definitions of output %q", name),
+					Subject:  &block.DefRange,
+				})
+			}
+
+			mod.Outputs[name] = o
+			mod.DeclaredAttributes[o.MapKey()] = content.Attributes
+
+			if attr, defined := content.Attributes["description"]; defined {
+				var description string
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &description)
+				diags = append(diags, valDiags...)
+				o.Description = description
+			}
+
+			if attr, defined := content.Attributes["sensitive"]; defined {
+				var sensitive bool
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sensitive)
+				diags = append(diags, valDiags...)
+				o.IsSensitive = sensitive
+			}
+
+			if attr, defined := content.Attributes["ephemeral"]; defined {
+				var ephemeral bool
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ephemeral)
+				diags = append(diags, valDiags...)
+				o.Ephemeral = ephemeral
+			}
+
+			if attr, defined := content.Attributes["value"]; defined {
+				o.Value = attr.Expr
+			}
+
+			if attr, defined := content.Attributes["depends_on"]; defined {
+				deps, depDiags := decodeDependsOn(attr)
+				diags = append(diags, depDiags...)
+				o.DependsOn = deps
+			}
+
+		case "provider":
+			content, _, contentDiags := block.Body.PartialContent(providerConfigSchema)
+			diags = append(diags, contentDiags...)
+
+			name := block.Labels[0]
+			// Even if there isn't an explicit version required, we still
+			// need an entry in our map to signal the unversioned dependency.
+			if _, exists := mod.ProviderRequirements[name]; !exists {
+				mod.ProviderRequirements[name] = &providerRequirement{}
+			}
+			if attr, defined := content.Attributes["version"]; defined {
+				var version string
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &version)
+				diags = append(diags, valDiags...)
+				if !valDiags.HasErrors() {
+					mod.ProviderRequirements[name].VersionConstraints = append(mod.ProviderRequirements[name].VersionConstraints, version)
+				}
+			}
+
+			providerKey := name
+			var alias string
+			aliasUnknown := false
+			if attr, defined := content.Attributes["alias"]; defined {
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &alias)
+				switch {
+				case valDiags.HasErrors():
+					// The alias may still be valid once evaluated, so the
+					// configuration is kept, just under a key which cannot
+					// clash with any other configuration
+					aliasUnknown = true
+					alias = ""
+					providerKey = fmt.Sprintf("%s.<unknown %s>", name, block.DefRange)
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagWarning,
+						Summary:  "Unknown provider alias",
+						Detail:   fmt.Sprintf("The alias of provider %q is not a literal string and cannot be determined without evaluating the configuration.", name),
+						Subject:  attr.Expr.Range().Ptr(),
+					})
+				case alias != "":
+					providerKey = fmt.Sprintf("%s.%s", name, alias)
+				}
+			}
+
+			if _, exists := mod.ProviderConfigs[providerKey]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple provider configurations",
+					Detail:   fmt.Sprintf("Found multiple configurations of provider %q", providerKey),
+					Subject:  &block.DefRange,
+				})
+			}
+
+			mod.ProviderConfigs[providerKey] = &providerConfig{
+				Name:             name,
+				Alias:            alias,
+				AliasUnknown:     aliasUnknown,
+				NestedBlockTypes: nestedBlockTypes(block.Body),
+				DeclRange:        block.DefRange,
+			}
+
+		case "data":
+			content, remain, contentDiags := block.Body.PartialContent(resourceSchema)
+			diags = append(diags, contentDiags...)
+
+			ds := &module.DataSource{
+				Type:      block.Labels[0],
+				Name:      block.Labels[1],
+				Body:      remain,
+				DeclRange: block.DefRange,
+			}
+
+			key := ds.MapKey()
+			if _, exists := mod.DataSources[key]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple data source definitions",
+					Detail:   fmt.Sprintf("Found multiple definitions of data source %q", key),
+					Subject:  &block.DefRange,
+				})
+			}
+
+			mod.DataSources[key] = ds
+			mod.DeclaredAttributes[key] = content.Attributes
+
+			count, forEach, rDiags := decodeRepetitionArguments(content)
+			diags = append(diags, rDiags...)
+			ds.Count, ds.ForEach = count, forEach
+
+			dynBlocks, dynDiags := decodeDynamicBlocks(content)
+			diags = append(diags, dynDiags...)
+			ds.DynamicBlocks = dynBlocks
+
+			for _, innerBlock := range content.Blocks {
+				if innerBlock.Type == "lifecycle" {
+					lifecycle, lAttrs, lDiags := decodeLifecycleBlock(innerBlock, dataLifecycleSchema)
+					diags = append(diags, lDiags...)
+					ds.Lifecycle = lifecycle
+					mod.LifecycleAttributes[key] = lAttrs
+				}
+			}
+
+			if attr, defined := content.Attributes["depends_on"]; defined {
+				deps, depDiags := decodeDependsOn(attr)
+				diags = append(diags, depDiags...)
+				ds.DependsOn = deps
+			}
+
+			if attr, defined := content.Attributes["provider"]; defined {
+				ref, aDiags := decodeProviderAttribute(attr)
+				diags = append(diags, aDiags...)
+				ds.Provider = ref
+				mod.ProviderAttrRanges[key] = attr.Expr.Range()
+			} else {
+				// If provider _isn't_ set then we'll infer it from the
+				// datasource type.
+				ds.Provider = module.ProviderRef{
+					LocalName: inferProviderNameFromType(ds.Type),
+				}
+			}
+
+		case "ephemeral":
+			content, _, contentDiags := block.Body.PartialContent(ephemeralResourceSchema)
+			diags = append(diags, contentDiags...)
+
+			er := &module.EphemeralResource{
+				Type:      block.Labels[0],
+				Name:      block.Labels[1],
+				DeclRange: block.DefRange,
+			}
+
+			key := er.MapKey()
+			if _, exists := mod.EphemeralResources[key]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple ephemeral resource definitions",
+					Detail:   fmt.Sprintf("Found multiple definitions of ephemeral resource %q", key),
+					Subject:  &block.DefRange,
+				})
+			}
+
+			mod.EphemeralResources[key] = er
+			mod.DeclaredAttributes[key] = content.Attributes
+
+			count, forEach, rDiags := decodeRepetitionArguments(content)
+			diags = append(diags, rDiags...)
+			er.Count, er.ForEach = count, forEach
+
+			if attr, defined := content.Attributes["depends_on"]; defined {
+				deps, depDiags := decodeDependsOn(attr)
+				diags = append(diags, depDiags...)
+				er.DependsOn = deps
+			}
+
+			if attr, defined := content.Attributes["provider"]; defined {
+				ref, aDiags := decodeProviderAttribute(attr)
+				diags = append(diags, aDiags...)
+				er.Provider = ref
+				mod.ProviderAttrRanges[key] = attr.Expr.Range()
+			} else {
+				er.Provider = module.ProviderRef{
+					LocalName: inferProviderNameFromType(er.Type),
+				}
+			}
+
+		case "resource":
+			content, remain, contentDiags := block.Body.PartialContent(resourceSchema)
+			diags = append(diags, contentDiags...)
+
+			r := &module.Resource{
+				Type:      block.Labels[0],
+				Name:      block.Labels[1],
+				Body:      remain,
+				DeclRange: block.DefRange,
+			}
+
+			key := r.MapKey()
+			if _, exists := mod.Resources[key]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple resource definitions",
+					Detail:   fmt.Sprintf("Found multiple definitions of resource %q", key),
+					Subject:  &block.DefRange,
+				})
+			}
+
+			mod.Resources[key] = r
+			mod.DeclaredAttributes[key] = content.Attributes
+
+			count, forEach, rDiags := decodeRepetitionArguments(content)
+			diags = append(diags, rDiags...)
+			r.Count, r.ForEach = count, forEach
+
+			dynBlocks, dynDiags := decodeDynamicBlocks(content)
+			diags = append(diags, dynDiags...)
+			r.DynamicBlocks = dynBlocks
+
+			for _, innerBlock := range content.Blocks {
+				switch innerBlock.Type {
+				case "lifecycle":
+					lifecycle, lAttrs, lDiags := decodeLifecycleBlock(innerBlock, resourceLifecycleSchema)
+					diags = append(diags, lDiags...)
+					r.Lifecycle = lifecycle
+					mod.LifecycleAttributes[key] = lAttrs
+				case "provisioner":
+					p, pDiags := decodeProvisionerBlock(innerBlock)
+					diags = append(diags, pDiags...)
+					r.Provisioners = append(r.Provisioners, p)
+				case "connection":
+					r.HasConnection = true
+				}
+			}
+
+			if attr, defined := content.Attributes["depends_on"]; defined {
+				deps, depDiags := decodeDependsOn(attr)
+				diags = append(diags, depDiags...)
+				r.DependsOn = deps
+			}
+
+			if attr, defined := content.Attributes["provider"]; defined {
+				ref, aDiags := decodeProviderAttribute(attr)
+				diags = append(diags, aDiags...)
+				r.Provider = ref
+				mod.ProviderAttrRanges[key] = attr.Expr.Range()
+			} else {
+				// If provider _isn't_ set then we'll infer it from the
+				// resource type.
+				r.Provider = module.ProviderRef{
+					LocalName: inferProviderNameFromType(r.Type),
+				}
+			}
+
+		case "module":
+			content, _, contentDiags := block.Body.PartialContent(moduleSchema)
+			diags = append(diags, contentDiags...)
+
+			ms := &module.ModuleSource{
+				Name:      block.Labels[0],
+				DeclRange: block.DefRange,
+			}
+			diags = append(diags, validateModuleName(ms.Name, block.LabelRanges[0])...)
+
+			// check if this is overriding an existing module
+			var origSource string
+			if origMod, exists := mod.ModuleSources[ms.MapKey()]; exists {
+				origSource = origMod.Source
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple module definitions",
+					Detail:   fmt.Sprintf("Found multiple definitions of module %q", ms.Name),
+					Subject:  &block.DefRange,
+				})
+			}
+
+			mod.ModuleSources[ms.MapKey()] = ms
+			mod.DeclaredAttributes[ms.MapKey()] = content.Attributes
+
+			if attr, defined := content.Attributes["source"]; defined {
+				var source string
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &source)
+				diags = append(diags, valDiags...)
+				ms.Source = source
+			}
+
+			if ms.Source == "" {
+				ms.Source = origSource
+			}
+
+			if attr, defined := content.Attributes["depends_on"]; defined {
+				deps, depDiags := decodeDependsOn(attr)
+				diags = append(diags, depDiags...)
+				ms.DependsOn = deps
+			}
+
+			if attr, defined := content.Attributes["providers"]; defined {
+				providers, pDiags := decodeModuleProviders(attr)
+				diags = append(diags, pDiags...)
+				ms.Providers = providers
+			}
+
+			if attr, defined := content.Attributes["version"]; defined {
+				var rawVersion string
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &rawVersion)
+				diags = append(diags, valDiags...)
+				if !valDiags.HasErrors() {
+					ms.Version = rawVersion
+					if _, err := version.NewConstraint(rawVersion); err != nil {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  fmt.Sprintf("Unable to parse %q module version", ms.Name),
+							Detail:   fmt.Sprintf("Constraint %q is not a valid constraint: %s", rawVersion, err),
+							Subject:  attr.Expr.Range().Ptr(),
+						})
+					}
+				}
+			}
+
+		case "moved":
+			content, _, contentDiags := block.Body.PartialContent(movedSchema)
+			diags = append(diags, contentDiags...)
+
+			m := &module.Moved{}
+
+			if attr, defined := content.Attributes["from"]; defined {
+				traversal, tDiags := decodeAddressAttribute(attr)
+				diags = append(diags, tDiags...)
+				m.From = traversal
+			}
+
+			if attr, defined := content.Attributes["to"]; defined {
+				traversal, tDiags := decodeAddressAttribute(attr)
+				diags = append(diags, tDiags...)
+				m.To = traversal
+			}
+
+			if m.From != nil && m.To != nil {
+				diags = append(diags, validateMoveEndpoints(m, block.DefRange)...)
+			}
+
+			mod.MovedBlocks = append(mod.MovedBlocks, m)
+
+		case "import":
+			content, _, contentDiags := block.Body.PartialContent(importSchema)
+			diags = append(diags, contentDiags...)
+
+			imp := &module.Import{}
+
+			if attr, defined := content.Attributes["to"]; defined {
+				traversal, tDiags := decodeImportTarget(attr)
+				diags = append(diags, tDiags...)
+				imp.To = traversal
+			}
+
+			if attr, defined := content.Attributes["id"]; defined {
+				imp.ID = attr.Expr
+			}
+
+			if attr, defined := content.Attributes["identity"]; defined {
+				if imp.ID != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Conflicting import identification",
+						Detail:   "Only one of the id and identity arguments can be set in an import block.",
+						Subject:  attr.NameRange.Ptr(),
+					})
+				} else {
+					imp.Identity = attr.Expr
+				}
+			}
+
+			if attr, defined := content.Attributes["provider"]; defined {
+				ref, aDiags := decodeProviderAttribute(attr)
+				diags = append(diags, aDiags...)
+				imp.Provider = ref
+			}
+
+			if attr, defined := content.Attributes["for_each"]; defined {
+				imp.ForEach = attr.Expr
+			}
+
+			mod.Imports = append(mod.Imports, imp)
+
+		case "removed":
+			content, _, contentDiags := block.Body.PartialContent(removedSchema)
+			diags = append(diags, contentDiags...)
+
+			r := &module.Removed{
+				Destroy: true,
+			}
+
+			if attr, defined := content.Attributes["from"]; defined {
+				traversal, tDiags := decodeAddressAttribute(attr)
+				diags = append(diags, tDiags...)
+				r.From = traversal
+			}
+
+			for _, innerBlock := range content.Blocks {
+				switch innerBlock.Type {
+				case "lifecycle":
+					lcContent, _, lcDiags := innerBlock.Body.PartialContent(removedLifecycleSchema)
+					diags = append(diags, lcDiags...)
+
+					if attr, defined := lcContent.Attributes["destroy"]; defined {
+						var destroy bool
+						valDiags := gohcl.DecodeExpression(attr.Expr, nil, &destroy)
+						diags = append(diags, valDiags...)
+						if !valDiags.HasErrors() {
+							r.Destroy = destroy
+						}
+					}
+				}
+			}
+
+			mod.Removed = append(mod.Removed, r)
+
+		case "check":
+			content, _, contentDiags := block.Body.PartialContent(checkSchema)
+			diags = append(diags, contentDiags...)
+
+			c := &module.Check{
+				Name:              block.Labels[0],
+				DataSources:       make([]string, 0),
+				ScopedDataSources: make(map[string]*module.DataSource, 0),
+			}
+
+			// Scoped data sources are not addressable from outside
+			// of the check, so they're kept out of mod.DataSources
+			for _, innerBlock := range content.Blocks {
+				switch innerBlock.Type {
+				case "data":
+					dsContent, dsRemain, dsDiags := innerBlock.Body.PartialContent(resourceSchema)
+					diags = append(diags, dsDiags...)
+
+					ds := &module.DataSource{
+						Type:      innerBlock.Labels[0],
+						Name:      innerBlock.Labels[1],
+						Body:      dsRemain,
+						DeclRange: innerBlock.DefRange,
+					}
+					key := ds.MapKey()
+
+					if attr, defined := dsContent.Attributes["provider"]; defined {
+						ref, aDiags := decodeProviderAttribute(attr)
+						diags = append(diags, aDiags...)
+						ds.Provider = ref
+						mod.ProviderAttrRanges[checkScopedKey(c.Name, key)] = attr.Expr.Range()
+					} else {
+						ds.Provider = module.ProviderRef{
+							LocalName: inferProviderNameFromType(ds.Type),
+						}
+					}
+
+					c.DataSources = append(c.DataSources, key)
+					c.ScopedDataSources[key] = ds
+				case "assert":
+					c.AssertionCount++
+				}
+			}
+
+			mod.Checks[c.Name] = c
+
+		case "provider_meta":
+			// provider_meta is only valid inside of the terraform block
+			// and would otherwise be silently mistaken for a provider
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Misplaced provider_meta block",
+				Detail:   fmt.Sprintf("The provider_meta block for provider %q must be nested inside of the terraform block.", block.Labels[0]),
+				Subject:  &block.DefRange,
+			})
+
+		default:
+			// Should never happen because our cases above should be
+			// exhaustive for our schema.
+			panic(fmt.Errorf("unhandled block type %q", block.Type))
+		}
+	}
+
+	return diags
+}
+
+func decodeProviderAttribute(attr *hcl.Attribute) (module.ProviderRef, hcl.Diagnostics) {
+	return decodeProviderExpression(attr.Expr)
+}
+
+func decodeProviderExpression(expr hcl.Expression) (module.ProviderRef, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	// New style here is to provide this as a naked traversal
+	// expression, but we also support quoted references for
+	// older configurations that predated this convention.
+	traversal, travDiags := hcl.AbsTraversalForExpr(expr)
+	if travDiags.HasErrors() {
+		traversal = nil // in case we got any partial results
+
+		// Fall back on trying to parse as a string
+		var travStr string
+		valDiags := gohcl.DecodeExpression(expr, nil, &travStr)
+		if !valDiags.HasErrors() {
+			var strDiags hcl.Diagnostics
+			traversal, strDiags = hclsyntax.ParseTraversalAbs([]byte(travStr), "", hcl.Pos{})
+			if strDiags.HasErrors() {
+				traversal = nil
+			}
+		}
+	}
+
+	// If we get out here with a nil traversal then we didn't
+	// succeed in processing the input.
+	if len(traversal) > 0 {
+		providerName := traversal.RootName()
+		alias := ""
+		if len(traversal) > 1 {
+			if getAttr, ok := traversal[1].(hcl.TraverseAttr); ok {
+				alias = getAttr.Name
+			}
+		}
+		return module.ProviderRef{
+			LocalName: providerName,
+			Alias:     alias,
+		}, diags
+	}
+
+	return module.ProviderRef{}, hcl.Diagnostics{
+		&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid provider reference",
+			Detail:   "Provider argument requires a provider name followed by an optional alias, like \"aws.foo\".",
+			Subject:  expr.Range().Ptr(),
+		},
+	}
+}
+
+// decodeAddressAttribute decodes an attribute referencing an object
+// in the module (such as a resource or a module call) into a traversal
+func decodeAddressAttribute(attr *hcl.Attribute) (hcl.Traversal, hcl.Diagnostics) {
+	traversal, travDiags := hcl.AbsTraversalForExpr(attr.Expr)
+	if travDiags.HasErrors() {
+		return nil, hcl.Diagnostics{
+			&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid address",
+				Detail:   fmt.Sprintf("The %q argument requires a reference to an object, like \"aws_instance.foo\" or \"module.bar\".", attr.Name),
+				Subject:  attr.Expr.Range().Ptr(),
+			},
+		}
+	}
+	return traversal, nil
+}
+
+// decodeImportTarget decodes the "to" attribute of an import block,
+// dropping any dynamic instance key such as [each.key]
+func decodeImportTarget(attr *hcl.Attribute) (hcl.Traversal, hcl.Diagnostics) {
+	if idxExpr, ok := attr.Expr.(*hclsyntax.IndexExpr); ok {
+		if _, diags := hcl.AbsTraversalForExpr(attr.Expr); diags.HasErrors() {
+			traversal, travDiags := hcl.AbsTraversalForExpr(idxExpr.Collection)
+			if !travDiags.HasErrors() {
+				return traversal, nil
+			}
+		}
+	}
+
+	return decodeAddressAttribute(attr)
+}
+
+// decodeRepetitionArguments returns count and for_each expressions
+// (nil if not declared) and reports an error if both are declared
+func decodeRepetitionArguments(content *hcl.BodyContent) (count, forEach hcl.Expression, diags hcl.Diagnostics) {
+	countAttr, countDefined := content.Attributes["count"]
+	if countDefined {
+		count = countAttr.Expr
+	}
+
+	forEachAttr, forEachDefined := content.Attributes["for_each"]
+	if forEachDefined {
+		forEach = forEachAttr.Expr
+	}
+
+	if countDefined && forEachDefined {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  `Invalid combination of "count" and "for_each"`,
+			Detail:   `The "count" and "for_each" meta-arguments are mutually-exclusive, only one should be used.`,
+			Subject:  &forEachAttr.NameRange,
+		})
+	}
+
+	return count, forEach, diags
+}
+
+// decodeLifecycleBlock decodes the lifecycle block of a resource
+// or a data source, as permitted by the given schema, and returns
+// the declared attributes alongside
+func decodeLifecycleBlock(block *hcl.Block, schema *hcl.BodySchema) (*module.Lifecycle, hcl.Attributes, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(schema)
+
+	lifecycle := &module.Lifecycle{}
+
+	if attr, defined := content.Attributes["create_before_destroy"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &lifecycle.CreateBeforeDestroy)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, defined := content.Attributes["prevent_destroy"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &lifecycle.PreventDestroy)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, defined := content.Attributes["ignore_changes"]; defined {
+		if hcl.ExprAsKeyword(attr.Expr) == "all" {
+			lifecycle.IgnoreAllChanges = true
+		} else {
+			exprs, listDiags := hcl.ExprList(attr.Expr)
+			diags = append(diags, listDiags...)
+			for _, expr := range exprs {
+				traversal, travDiags := hcl.RelTraversalForExpr(expr)
+				if travDiags.HasErrors() {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Invalid ignore_changes reference",
+						Detail:   "ignore_changes can only contain references to attributes of the resource, like tags.",
+						Subject:  expr.Range().Ptr(),
+					})
+					continue
+				}
+				lifecycle.IgnoreChanges = append(lifecycle.IgnoreChanges, traversal)
+			}
+		}
+	}
+
+	if attr, defined := content.Attributes["replace_triggered_by"]; defined {
+		exprs, listDiags := hcl.ExprList(attr.Expr)
+		diags = append(diags, listDiags...)
+		lifecycle.ReplaceTriggeredBy = exprs
+	}
+
+	for _, innerBlock := range content.Blocks {
+		rule, ruleDiags := decodeCheckRule(innerBlock)
+		diags = append(diags, ruleDiags...)
+
+		switch innerBlock.Type {
+		case "precondition":
+			lifecycle.Preconditions = append(lifecycle.Preconditions, rule)
+		case "postcondition":
+			lifecycle.Postconditions = append(lifecycle.Postconditions, rule)
+		}
+	}
+
+	return lifecycle, content.Attributes, diags
+}
+
+// decodeProvisionerBlock decodes the type of the provisioner
+// and when it runs, leaving the rest of the body undecoded
+func decodeProvisionerBlock(block *hcl.Block) (*module.Provisioner, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(provisionerSchema)
+
+	p := &module.Provisioner{
+		Type:          block.Labels[0],
+		HasConnection: len(content.Blocks) > 0,
+		DeclRange:     block.DefRange,
+	}
+
+	if attr, defined := content.Attributes["when"]; defined {
+		switch when := hcl.ExprAsKeyword(attr.Expr); when {
+		case "destroy":
+			p.WhenDestroy = true
+		case "create":
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid \"when\" keyword",
+				Detail:   "The \"when\" argument requires one of the following keywords: create or destroy.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	return p, diags
+}
+
+// decodeCheckRule decodes a block containing a condition
+// and an error message, such as precondition or validation
+func decodeCheckRule(block *hcl.Block) (*module.CheckRule, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(checkRuleSchema)
+
+	rule := &module.CheckRule{}
+	if attr, defined := content.Attributes["condition"]; defined {
+		rule.Condition = attr.Expr
+	}
+	if attr, defined := content.Attributes["error_message"]; defined {
+		rule.ErrorMessage = attr.Expr
+	}
+
+	return rule, diags
+}
+
+// decodeDynamicBlocks decodes dynamic blocks found in the given
+// body content, which are nil if none were declared
+func decodeDynamicBlocks(content *hcl.BodyContent) ([]*module.DynamicBlock, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	var blocks []*module.DynamicBlock
+
+	for _, block := range content.Blocks {
+		if block.Type != "dynamic" {
+			continue
+		}
+
+		dynContent, _, contentDiags := block.Body.PartialContent(dynamicBlockSchema)
+		diags = append(diags, contentDiags...)
+
+		db := &module.DynamicBlock{
+			Type:     block.Labels[0],
+			Iterator: block.Labels[0],
+		}
+
+		if attr, defined := dynContent.Attributes["for_each"]; defined {
+			db.ForEach = attr.Expr
+		}
+
+		if attr, defined := dynContent.Attributes["iterator"]; defined {
+			iterator := hcl.ExprAsKeyword(attr.Expr)
+			if iterator == "" {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid dynamic iterator name",
+					Detail:   "Dynamic iterator must be a single variable name.",
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			} else {
+				db.Iterator = iterator
+			}
+		}
+
+		for _, contentBlock := range dynContent.Blocks {
+			if db.Content != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Extraneous dynamic block content",
+					Detail:   "A dynamic block may have only one content block.",
+					Subject:  &contentBlock.DefRange,
+				})
+				continue
+			}
+			db.Content = contentBlock.Body
+		}
+
+		blocks = append(blocks, db)
+	}
+
+	return blocks, diags
+}
+
+// decodeModuleProviders decodes the providers attribute of a module call,
+// mapping provider references in the child module (keys)
+// to provider references in the calling module (values)
+func decodeModuleProviders(attr *hcl.Attribute) (map[string]module.ProviderRef, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	providers := make(map[string]module.ProviderRef, 0)
+
+	kvs, mapDiags := hcl.ExprMap(attr.Expr)
+	if mapDiags.HasErrors() {
+		return providers, mapDiags
+	}
+
+	for _, kv := range kvs {
+		childRef, keyDiags := decodeProviderExpression(kv.Key)
+		if keyDiags.HasErrors() {
+			diags = append(diags, keyDiags...)
+			continue
+		}
+
+		parentRef, valDiags := decodeProviderExpression(kv.Value)
+		if valDiags.HasErrors() {
+			diags = append(diags, valDiags...)
+			continue
+		}
+
+		providers[childRef.String()] = parentRef
+	}
+
+	return providers, diags
+}
+
+// knownExperiments represents experiments known at the time of writing.
+// Experiments come and go between Terraform versions, so unknown
+// ones are reported as warnings only.
+var knownExperiments = map[string]bool{
+	"module_variable_optional_attrs":    true,
+	"suppress_provider_sensitive_attrs": true,
+	"provider_sensitive_attrs":          true,
+	"config_driven_move":                true,
+	"variable_validation":               true,
+}
+
+// decodeRequiredVersion decodes the required_version argument,
+// which is normally a single constraint string, but generated
+// configurations occasionally use a list of constraint strings.
+//
+// Templates and heredocs are accepted as long as they evaluate
+// to constant strings, whose surrounding whitespace is trimmed.
+func decodeRequiredVersion(attr *hcl.Attribute) ([]string, hcl.Diagnostics) {
+	invalidDiags := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid required_version",
+			Detail:   "The required_version argument must be a version constraint string, or a list of such strings.",
+			Subject:  attr.Expr.Range().Ptr(),
+		},
+	}
+
+	val, diags := attr.Expr.Value(&hcl.EvalContext{})
+	if diags.HasErrors() {
+		if len(attr.Expr.Variables()) > 0 {
+			return nil, hcl.Diagnostics{
+				{
+					Severity: hcl.DiagWarning,
+					Summary:  "Non-constant required_version",
+					Detail:   "The required_version argument refers to other values, which cannot be evaluated this early, so the core requirements are ignored.",
+					Subject:  attr.Expr.Range().Ptr(),
+				},
+			}
+		}
+		return nil, invalidDiags
+	}
+
+	if ty := val.Type(); !val.IsNull() && (ty.IsTupleType() || ty.IsListType()) {
+		constraints := make([]string, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			constraint, ok := constraintString(v)
+			if !ok {
+				return nil, invalidDiags
+			}
+			constraints = append(constraints, constraint)
+		}
+		return constraints, nil
+	}
+
+	constraint, ok := constraintString(val)
+	if !ok {
+		return nil, invalidDiags
+	}
+	return []string{constraint}, nil
+}
+
+// constraintString converts the given value to a string
+// the same way as gohcl would, if it is a primitive value
+func constraintString(val cty.Value) (string, bool) {
+	if val.IsNull() || !val.IsKnown() || !val.Type().IsPrimitiveType() {
+		return "", false
+	}
+	strVal, err := convert.Convert(val, cty.String)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(strVal.AsString()), true
+}
+
+func decodeExperiments(attr *hcl.Attribute) ([]string, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	experiments := make([]string, 0)
+
+	exprs, listDiags := hcl.ExprList(attr.Expr)
+	if listDiags.HasErrors() {
+		return experiments, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagWarning,
+				Summary:  "Invalid experiments",
+				Detail:   "The experiments argument requires a list of experiment keywords.",
+				Subject:  attr.Expr.Range().Ptr(),
+			},
+		}
+	}
+
+	for _, expr := range exprs {
+		name := hcl.ExprAsKeyword(expr)
+		if name == "" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Invalid experiment keyword",
+				Detail:   "Elements of \"experiments\" must all be keywords representing active experiments.",
+				Subject:  expr.Range().Ptr(),
+			})
+			continue
+		}
+
+		if !knownExperiments[name] {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Unknown experiment keyword",
+				Detail:   fmt.Sprintf("There is no known experiment with the name %q.", name),
+				Subject:  expr.Range().Ptr(),
+			})
+		}
+		experiments = append(experiments, name)
+	}
+
+	return experiments, diags
+}
+
+// decodeDependsOn decodes the depends_on attribute into traversals,
+// which are kept unresolved as they may point to other files
+func decodeDependsOn(attr *hcl.Attribute) ([]hcl.Traversal, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	exprs, listDiags := hcl.ExprList(attr.Expr)
+	if listDiags.HasErrors() {
+		return nil, listDiags
+	}
+
+	deps := make([]hcl.Traversal, 0, len(exprs))
+	for _, expr := range exprs {
+		traversal, travDiags := hcl.AbsTraversalForExpr(expr)
+		if travDiags.HasErrors() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid depends_on reference",
+				Detail:   "References in depends_on must be to a whole object, like \"aws_instance.foo\".",
+				Subject:  expr.Range().Ptr(),
+			})
+			continue
+		}
+		deps = append(deps, traversal)
+	}
+
+	return deps, diags
+}
+
+// nestedBlockTypes returns sorted unique types of blocks nested
+// directly in the given body, or nil if there are none.
+//
+// Blocks cannot be told apart from attributes in JSON
+// without a schema, so JSON bodies are never reported to have any.
+func nestedBlockTypes(body hcl.Body) []string {
+	synBody, ok := body.(*hclsyntax.Body)
+	if !ok || len(synBody.Blocks) == 0 {
+		return nil
+	}
+
+	types := make([]string, 0, len(synBody.Blocks))
+	seen := make(map[string]bool, len(synBody.Blocks))
+	for _, block := range synBody.Blocks {
+		if seen[block.Type] {
+			continue
+		}
+		seen[block.Type] = true
+		types = append(types, block.Type)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// addProviderRequirements merges the given requirements decoded
+// from a required_providers block or attribute into the module
+func addProviderRequirements(mod *decodedModule, reqs map[string]*providerRequirement, declRange hcl.Range) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for name, req := range reqs {
+		if _, exists := mod.ProviderRequirements[name]; !exists {
+			mod.ProviderRequirements[name] = req
+		} else {
+			if req.Source != "" {
+				// sources are compared as normalized, the same way as across files
+				source := mod.ProviderRequirements[name].Source
+				if source != "" && mod.ProviderRequirements[name].MapKey(name) != req.MapKey(name) {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Multiple provider source attributes",
+						Detail:   fmt.Sprintf("Found multiple source attributes for provider %s: %q, %q", name, source, req.Source),
+						Subject:  declRange.Ptr(),
+					})
+				} else {
+					mod.ProviderRequirements[name].Source = req.Source
+				}
+			}
+
+			mod.ProviderRequirements[name].VersionConstraints = append(mod.ProviderRequirements[name].VersionConstraints, req.VersionConstraints...)
+			mod.ProviderRequirements[name].ConfigurationAliases = append(mod.ProviderRequirements[name].ConfigurationAliases, req.ConfigurationAliases...)
+			// the entry may have been implied by a preceding provider block
+			if len(mod.ProviderRequirements[name].DeclRanges) == 0 {
+				mod.ProviderRequirements[name].DeclRange = req.DeclRange
+			}
+			mod.ProviderRequirements[name].DeclRanges = append(mod.ProviderRequirements[name].DeclRanges, req.DeclRanges...)
+		}
+	}
+	return diags
+}
+
+// inferProviderNameFromType returns the lowercased provider local name
+// implied by the given resource or data source type, i.e. its first
+// non-empty underscore-separated segment
+func inferProviderNameFromType(typeName string) string {
+	// Leading underscores would otherwise yield an empty name
+	typeName = strings.TrimLeft(typeName, "_")
+
+	underscore := strings.Index(typeName, "_")
+	if underscore == -1 {
+		// If the resource name does not contain an underscore,
+		// we assume this is a provider name, such as "null".
+		return strings.ToLower(typeName)
+	}
+	return strings.ToLower(typeName[:underscore])
+}
+
+// decodeVariableType decodes the type constraint of a variable,
+// falling back to cty.DynamicPseudoType where it cannot be decoded
+func decodeVariableType(expr hcl.Expression) cty.Type {
+	// Older versions of Terraform expected the type to be a string
+	// containing a keyword, so we handle that as a special case first
+	// for backward compatibility.
+	// Strings in JSON represent type expressions, so these are
+	// left to typeexpr instead.
+	if _, ok := expr.(*hclsyntax.TemplateExpr); ok {
+		var typeStr string
+		valDiags := gohcl.DecodeExpression(expr, nil, &typeStr)
+		if !valDiags.HasErrors() {
+			switch typeStr {
+			case "string":
+				return cty.String
+			case "list":
+				return cty.List(cty.DynamicPseudoType)
+			case "map":
+				return cty.Map(cty.DynamicPseudoType)
+			}
+		}
+		return cty.DynamicPseudoType
+	}
+
+	typ, typeDiags := typeexpr.TypeConstraint(expr)
+	if typeDiags.HasErrors() {
+		return cty.DynamicPseudoType
+	}
+	return typ
+}
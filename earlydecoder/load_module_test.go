@@ -0,0 +1,1518 @@
+package earlydecoder
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-schema/module"
+	"github.com/zclconf/go-cty-debug/ctydebug"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestLoadModuleFromFile_variables(t *testing.T) {
+	testCases := []struct {
+		name              string
+		cfg               string
+		expectedVariables map[string]*module.Variable
+	}{
+		{
+			"no variables",
+			``,
+			map[string]*module.Variable{},
+		},
+		{
+			"untyped variable",
+			`variable "name" {}`,
+			map[string]*module.Variable{
+				"name": {
+					Name:       "name",
+					Type:       cty.DynamicPseudoType,
+					IsNullable: true,
+				},
+			},
+		},
+		{
+			"complex object type with default",
+			`
+variable "settings" {
+  description = "Settings of the thing"
+  type = object({
+    name = string
+    tags = map(string)
+    ports = list(number)
+  })
+  default = {
+    name = "example"
+    tags = {
+      env = "test"
+    }
+    ports = [80, 443]
+  }
+  sensitive = true
+  nullable  = false
+}
+`,
+			map[string]*module.Variable{
+				"settings": {
+					Name:        "settings",
+					Description: "Settings of the thing",
+					Type: cty.Object(map[string]cty.Type{
+						"name":  cty.String,
+						"tags":  cty.Map(cty.String),
+						"ports": cty.List(cty.Number),
+					}),
+					DefaultValue: cty.ObjectVal(map[string]cty.Value{
+						"name": cty.StringVal("example"),
+						"tags": cty.ObjectVal(map[string]cty.Value{
+							"env": cty.StringVal("test"),
+						}),
+						"ports": cty.TupleVal([]cty.Value{
+							cty.NumberIntVal(80),
+							cty.NumberIntVal(443),
+						}),
+					}),
+					IsSensitive: true,
+					IsNullable:  false,
+				},
+			},
+		},
+		{
+			"legacy string type",
+			`
+variable "legacy" {
+  type = "map"
+}
+`,
+			map[string]*module.Variable{
+				"legacy": {
+					Name:       "legacy",
+					Type:       cty.Map(cty.DynamicPseudoType),
+					IsNullable: true,
+				},
+			},
+		},
+		{
+			"undecodable type",
+			`
+variable "future" {
+  type = futuretype(string)
+}
+`,
+			map[string]*module.Variable{
+				"future": {
+					Name:       "future",
+					Type:       cty.DynamicPseudoType,
+					IsNullable: true,
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.name), func(t *testing.T) {
+			f, diags := hclsyntax.ParseConfig([]byte(tc.cfg), "test.tf", hcl.InitialPos)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			mod := newDecodedModule()
+			diags = loadModuleFromFile(f, mod)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			if diff := cmp.Diff(tc.expectedVariables, mod.Variables, ctydebug.CmpOptions, ignoreDeclRanges); diff != "" {
+				t.Fatalf("variables don't match: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_outputs(t *testing.T) {
+	testCases := []struct {
+		name            string
+		cfg             string
+		expectedOutputs map[string]*module.Output
+	}{
+		{
+			"no outputs",
+			``,
+			map[string]*module.Output{},
+		},
+		{
+			"sensitive output",
+			`
+output "password" {
+  description = "Admin password"
+  value       = var.password
+  sensitive   = true
+}
+`,
+			map[string]*module.Output{
+				"password": {
+					Name:        "password",
+					Description: "Admin password",
+					IsSensitive: true,
+				},
+			},
+		},
+		{
+			"output with depends_on",
+			`
+output "ip" {
+  value      = aws_instance.web.public_ip
+  depends_on = [aws_security_group.web, module.network]
+}
+`,
+			map[string]*module.Output{
+				"ip": {
+					Name: "ip",
+					DependsOn: []hcl.Traversal{
+						mustTraversal(t, "aws_security_group.web"),
+						mustTraversal(t, "module.network"),
+					},
+				},
+			},
+		},
+	}
+
+	opts := cmp.Options{
+		cmp.Comparer(compareTraversal),
+		cmpopts.IgnoreFields(module.Output{}, "Value"),
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.name), func(t *testing.T) {
+			f, diags := hclsyntax.ParseConfig([]byte(tc.cfg), "test.tf", hcl.InitialPos)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			mod := newDecodedModule()
+			diags = loadModuleFromFile(f, mod)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			if diff := cmp.Diff(tc.expectedOutputs, mod.Outputs, opts, ignoreDeclRanges); diff != "" {
+				t.Fatalf("outputs don't match: %s", diff)
+			}
+			for name, o := range mod.Outputs {
+				if o.Value == nil {
+					t.Fatalf("expected value expression for output %q", name)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_duplicateOutputs(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "first.tf", `output "ip" { value = "a" }`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	diags = loadModuleFromFile(mustParseFile(t, "second.tf", `output "ip" { value = "b" }`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Multiple output definitions" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if diags[0].Subject.Filename != "second.tf" {
+		t.Fatalf("expected diagnostic to point to second.tf, given: %s", diags[0].Subject)
+	}
+}
+
+func TestLoadModuleFromFile_duplicateBlocks(t *testing.T) {
+	testCases := []struct {
+		name            string
+		cfg             string
+		expectedSummary string
+	}{
+		{
+			"resource",
+			`resource "aws_instance" "web" {}`,
+			"Multiple resource definitions",
+		},
+		{
+			"data source",
+			`data "aws_ami" "ubuntu" {}`,
+			"Multiple data source definitions",
+		},
+		{
+			"module",
+			`module "vpc" {
+  source = "./vpc"
+}`,
+			"Multiple module definitions",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.name), func(t *testing.T) {
+			mod := newDecodedModule()
+			diags := loadModuleFromFile(mustParseFile(t, "first.tf", tc.cfg), mod)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+			diags = loadModuleFromFile(mustParseFile(t, "second.tf", "\n"+tc.cfg), mod)
+			if len(diags) != 1 {
+				t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+			}
+			if diags[0].Summary != tc.expectedSummary {
+				t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+			}
+			if diags[0].Subject.Filename != "second.tf" || diags[0].Subject.Start.Line != 2 {
+				t.Fatalf("expected diagnostic to point to the second declaration, given: %s", diags[0].Subject)
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_duplicateProviderConfigs(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  string
+	}{
+		{
+			"aliased",
+			`
+provider "aws" {
+  alias = "foo"
+}
+
+provider "aws" {
+  region = "eu-west-1"
+}
+
+provider "aws" {
+  alias = "foo"
+}
+`,
+		},
+		{
+			"default",
+			`
+provider "aws" {
+  alias = "foo"
+}
+
+provider "aws" {
+  region = "eu-west-1"
+}
+
+provider "aws" {
+  region = "eu-west-2"
+}
+`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.name), func(t *testing.T) {
+			mod := newDecodedModule()
+			diags := loadModuleFromFile(mustParseFile(t, "test.tf", tc.cfg), mod)
+			if len(diags) != 1 {
+				t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+			}
+			if diags[0].Summary != "Multiple provider configurations" {
+				t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+			}
+			expectedRange := &hcl.Range{
+				Filename: "test.tf",
+				Start:    hcl.Pos{Line: 10, Column: 1, Byte: 80},
+				End:      hcl.Pos{Line: 10, Column: 15, Byte: 94},
+			}
+			if diff := cmp.Diff(expectedRange, diags[0].Subject); diff != "" {
+				t.Fatalf("unexpected diagnostic range: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_invalidModuleNames(t *testing.T) {
+	testCases := []struct {
+		cfg             string
+		expectedSummary string
+	}{
+		{
+			`module "1vpc" {
+  source = "./vpc"
+}`,
+			"Invalid module instance name",
+		},
+		{
+			`module "terraform" {
+  source = "./vpc"
+}`,
+			"Reserved module name",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			mod := newDecodedModule()
+			diags := loadModuleFromFile(mustParseFile(t, "test.tf", tc.cfg), mod)
+			if len(diags) != 1 {
+				t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+			}
+			if diags[0].Summary != tc.expectedSummary {
+				t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+			}
+			expectedRange := &hcl.Range{
+				Filename: "test.tf",
+				Start:    hcl.Pos{Line: 1, Column: 8, Byte: 7},
+			}
+			if diff := cmp.Diff(expectedRange.Start, diags[0].Subject.Start); diff != "" {
+				t.Fatalf("expected diagnostic to point to the label: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_experiments(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  experiments = [module_variable_optional_attrs]
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedExperiments := []string{"module_variable_optional_attrs"}
+	if diff := cmp.Diff(expectedExperiments, mod.Experiments); diff != "" {
+		t.Fatalf("experiments don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_experimentsInvalid(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  experiments = [future_experiment, "quoted"]
+}
+`), mod)
+	if len(diags) != 2 {
+		t.Fatalf("expected exactly 2 diagnostics, %d given: %s", len(diags), diags)
+	}
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagWarning {
+			t.Fatalf("expected warning, given: %s", diag.Summary)
+		}
+	}
+
+	expectedExperiments := []string{"future_experiment"}
+	if diff := cmp.Diff(expectedExperiments, mod.Experiments); diff != "" {
+		t.Fatalf("experiments don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_dynamicBlocks(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+resource "aws_security_group" "test" {
+  name = "test"
+
+  dynamic "ingress" {
+    for_each = var.ingress_ports
+    content {
+      from_port = ingress.value
+      to_port   = ingress.value
+    }
+  }
+
+  dynamic "egress" {
+    for_each = var.egress_rules
+    iterator = rule
+    content {
+      from_port = rule.value.port
+    }
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	r, ok := mod.Resources["aws_security_group.test"]
+	if !ok {
+		t.Fatalf("expected resource to be decoded")
+	}
+	if len(r.DynamicBlocks) != 2 {
+		t.Fatalf("expected 2 dynamic blocks, %d given", len(r.DynamicBlocks))
+	}
+
+	expected := []struct {
+		blockType, iterator, forEach string
+	}{
+		{"ingress", "ingress", "var.ingress_ports"},
+		{"egress", "rule", "var.egress_rules"},
+	}
+	for i, db := range r.DynamicBlocks {
+		if db.Type != expected[i].blockType {
+			t.Fatalf("%d: expected type %q, given: %q", i, expected[i].blockType, db.Type)
+		}
+		if db.Iterator != expected[i].iterator {
+			t.Fatalf("%d: expected iterator %q, given: %q", i, expected[i].iterator, db.Iterator)
+		}
+		traversal, tDiags := hcl.AbsTraversalForExpr(db.ForEach)
+		if tDiags.HasErrors() {
+			t.Fatal(tDiags)
+		}
+		if !compareTraversal(traversal, mustTraversal(t, expected[i].forEach)) {
+			t.Fatalf("%d: unexpected for_each: %s", i, traversalString(traversal))
+		}
+		if db.Content == nil {
+			t.Fatalf("%d: expected content to be decoded", i)
+		}
+	}
+}
+
+func TestLoadModuleFromFile_lifecycle(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+resource "aws_instance" "web" {
+  lifecycle {
+    create_before_destroy = true
+    ignore_changes        = [tags]
+    replace_triggered_by  = [aws_ami.ubuntu.id]
+
+    precondition {
+      condition     = data.aws_ami.ubuntu.architecture == "x86_64"
+      error_message = "The selected AMI must be for the x86_64 architecture."
+    }
+  }
+}
+
+resource "aws_instance" "db" {
+  lifecycle {
+    prevent_destroy = true
+    ignore_changes  = all
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  lifecycle {
+    postcondition {
+      condition     = self.tags["Component"] == "nomad-server"
+      error_message = "The selected AMI must be tagged."
+    }
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	web := mod.Resources["aws_instance.web"].Lifecycle
+	if web == nil {
+		t.Fatal("expected lifecycle of aws_instance.web to be decoded")
+	}
+	if !web.CreateBeforeDestroy || web.PreventDestroy || web.IgnoreAllChanges {
+		t.Fatalf("unexpected lifecycle flags: %#v", web)
+	}
+	if len(web.IgnoreChanges) != 1 || traversalString(web.IgnoreChanges[0]) != ".tags" {
+		t.Fatalf("unexpected ignore_changes: %#v", web.IgnoreChanges)
+	}
+	if len(web.ReplaceTriggeredBy) != 1 {
+		t.Fatalf("expected 1 replace_triggered_by entry, %d given", len(web.ReplaceTriggeredBy))
+	}
+	if len(web.Preconditions) != 1 || len(web.Postconditions) != 0 {
+		t.Fatalf("expected 1 precondition, given: %#v", web)
+	}
+	if web.Preconditions[0].Condition == nil || web.Preconditions[0].ErrorMessage == nil {
+		t.Fatalf("expected precondition expressions to be decoded")
+	}
+
+	db := mod.Resources["aws_instance.db"].Lifecycle
+	if !db.PreventDestroy || !db.IgnoreAllChanges || len(db.IgnoreChanges) != 0 {
+		t.Fatalf("unexpected lifecycle: %#v", db)
+	}
+
+	ami := mod.DataSources["data.aws_ami.ubuntu"].Lifecycle
+	if len(ami.Postconditions) != 1 {
+		t.Fatalf("expected 1 postcondition, given: %#v", ami)
+	}
+}
+
+func TestValidateProviderAliases(t *testing.T) {
+	testCases := []struct {
+		name          string
+		cfg           string
+		expectedDiags int
+	}{
+		{
+			"dangling alias",
+			`
+provider "aws" {}
+
+resource "aws_instance" "web" {
+  provider = aws.west
+}
+`,
+			1,
+		},
+		{
+			"aliased provider block",
+			`
+provider "aws" {
+  alias = "west"
+}
+
+resource "aws_instance" "web" {
+  provider = aws.west
+}
+
+resource "aws_instance" "db" {
+  provider = aws
+}
+`,
+			0,
+		},
+		{
+			"configuration alias",
+			`
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      configuration_aliases = [aws.west]
+    }
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  provider = aws.west
+}
+`,
+			0,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.name), func(t *testing.T) {
+			mod := newDecodedModule()
+			diags := loadModuleFromFile(mustParseFile(t, "test.tf", tc.cfg), mod)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			diags = validateProviderAliases(mod)
+			if len(diags) != tc.expectedDiags {
+				t.Fatalf("expected %d diagnostics, %d given: %s", tc.expectedDiags, len(diags), diags)
+			}
+			if tc.expectedDiags > 0 {
+				expectedRange := &hcl.Range{
+					Filename: "test.tf",
+					Start:    hcl.Pos{Line: 5, Column: 14, Byte: 65},
+					End:      hcl.Pos{Line: 5, Column: 22, Byte: 73},
+				}
+				if diff := cmp.Diff(expectedRange, diags[0].Subject); diff != "" {
+					t.Fatalf("unexpected diagnostic range: %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_declRanges(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `variable "name" {}
+output "id" {}
+provider "aws" {}
+resource "aws_instance" "web" {}
+data "aws_ami" "ubuntu" {}
+module "vpc" {}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	rng := func(line, startByte, endByte int) hcl.Range {
+		return hcl.Range{
+			Filename: "test.tf",
+			Start:    hcl.Pos{Line: line, Column: 1, Byte: startByte},
+			End:      hcl.Pos{Line: line, Column: endByte - startByte + 1, Byte: endByte},
+		}
+	}
+
+	expectedRanges := map[string]hcl.Range{
+		"variable": rng(1, 0, 15),
+		"output":   rng(2, 19, 30),
+		"provider": rng(3, 34, 48),
+		"resource": rng(4, 52, 81),
+		"data":     rng(5, 85, 108),
+		"module":   rng(6, 112, 124),
+	}
+	givenRanges := map[string]hcl.Range{
+		"variable": mod.Variables["name"].DeclRange,
+		"output":   mod.Outputs["id"].DeclRange,
+		"provider": mod.ProviderConfigs["aws"].DeclRange,
+		"resource": mod.Resources["aws_instance.web"].DeclRange,
+		"data":     mod.DataSources["data.aws_ami.ubuntu"].DeclRange,
+		"module":   mod.ModuleSources["module.vpc"].DeclRange,
+	}
+	if diff := cmp.Diff(expectedRanges, givenRanges); diff != "" {
+		t.Fatalf("unexpected ranges: %s", diff)
+	}
+}
+
+func mustParseFile(t testing.TB, filename, cfg string) *hcl.File {
+	f, diags := hclsyntax.ParseConfig([]byte(cfg), filename, hcl.InitialPos)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	return f
+}
+
+func mustTraversal(t *testing.T, expr string) hcl.Traversal {
+	traversal, diags := hclsyntax.ParseTraversalAbs([]byte(expr), "", hcl.InitialPos)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	return traversal
+}
+
+// compareTraversal compares traversals by their steps, ignoring ranges
+func compareTraversal(x, y hcl.Traversal) bool {
+	return traversalString(x) == traversalString(y)
+}
+
+func traversalString(traversal hcl.Traversal) string {
+	var s string
+	for _, step := range traversal {
+		switch ts := step.(type) {
+		case hcl.TraverseRoot:
+			s += ts.Name
+		case hcl.TraverseAttr:
+			s += "." + ts.Name
+		case hcl.TraverseIndex:
+			s += fmt.Sprintf("[%#v]", ts.Key)
+		default:
+			s += fmt.Sprintf("<%T>", ts)
+		}
+	}
+	return s
+}
+
+func TestLoadModuleFromFile_locals(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "first.tf", `
+locals {
+  name = "example"
+  tags = {
+    env = "test"
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	diags = loadModuleFromFile(mustParseFile(t, "second.tf", `
+locals {
+  region = "eu-west-2"
+}
+
+locals {
+  name = "other"
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Duplicate local value definition" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	expectedRange := &hcl.Range{
+		Filename: "second.tf",
+		Start:    hcl.Pos{Line: 7, Column: 3, Byte: 47},
+		End:      hcl.Pos{Line: 7, Column: 7, Byte: 51},
+	}
+	if diff := cmp.Diff(expectedRange, diags[0].Subject); diff != "" {
+		t.Fatalf("unexpected diagnostic range: %s", diff)
+	}
+
+	names := make([]string, 0)
+	for name := range mod.Locals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expectedNames := []string{"name", "region", "tags"}
+	if diff := cmp.Diff(expectedNames, names); diff != "" {
+		t.Fatalf("unexpected locals: %s", diff)
+	}
+
+	// first definition wins
+	val, _ := mod.Locals["name"].Expr.Value(nil)
+	if val.AsString() != "example" {
+		t.Fatalf("unexpected value of local.name: %#v", val)
+	}
+}
+
+func TestLoadModuleFromFile_backend(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  backend "s3" {
+    bucket = "mybucket"
+    key    = "path/to/my/key"
+    region = "us-east-1"
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if mod.Backend == nil {
+		t.Fatal("expected backend to be decoded")
+	}
+	if mod.Backend.Type != "s3" {
+		t.Fatalf("unexpected backend type: %q", mod.Backend.Type)
+	}
+
+	attrs, diags := mod.Backend.Config.JustAttributes()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	bucket, _ := attrs["bucket"].Expr.Value(nil)
+	if bucket.AsString() != "mybucket" {
+		t.Fatalf("unexpected bucket: %#v", bucket)
+	}
+}
+
+func TestLoadModuleFromFile_multipleBackends(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  backend "s3" {}
+  backend "gcs" {}
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Multiple backend definitions" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if mod.Backend.Type != "s3" {
+		t.Fatalf("expected first backend to be kept, given: %q", mod.Backend.Type)
+	}
+}
+
+func TestLoadModuleFromFile_cloud(t *testing.T) {
+	testCases := []struct {
+		name          string
+		cfg           string
+		expectedCloud *module.CloudConfig
+	}{
+		{
+			"tag-based workspaces",
+			`
+terraform {
+  cloud {
+    organization = "example-org"
+
+    workspaces {
+      tags = ["app", "prod"]
+    }
+  }
+}
+`,
+			&module.CloudConfig{
+				Organization: "example-org",
+				Workspaces: &module.CloudWorkspaces{
+					Tags: []string{"app", "prod"},
+				},
+			},
+		},
+		{
+			"named workspace",
+			`
+terraform {
+  cloud {
+    organization = "example-org"
+    hostname     = "tfe.example.com"
+
+    workspaces {
+      name    = "networking"
+      project = "infra"
+    }
+  }
+}
+`,
+			&module.CloudConfig{
+				Organization: "example-org",
+				Hostname:     "tfe.example.com",
+				Workspaces: &module.CloudWorkspaces{
+					Name:    "networking",
+					Project: "infra",
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.name), func(t *testing.T) {
+			mod := newDecodedModule()
+			diags := loadModuleFromFile(mustParseFile(t, "test.tf", tc.cfg), mod)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			if diff := cmp.Diff(tc.expectedCloud, mod.Cloud); diff != "" {
+				t.Fatalf("cloud config doesn't match: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_cloudAndBackend(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  backend "s3" {}
+  cloud {
+    organization = "example-org"
+  }
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Both cloud and backend blocks found" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if mod.Cloud != nil {
+		t.Fatalf("expected cloud block to be ignored, given: %#v", mod.Cloud)
+	}
+}
+
+func TestLoadModuleFromFile_moved(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+moved {
+  from = aws_instance.a
+  to   = aws_instance.b
+}
+
+moved {
+  from = module.old
+  to   = module.new["key"]
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedMoved := []*module.Moved{
+		{
+			From: mustTraversal(t, "aws_instance.a"),
+			To:   mustTraversal(t, "aws_instance.b"),
+		},
+		{
+			From: mustTraversal(t, "module.old"),
+			To:   mustTraversal(t, `module.new["key"]`),
+		},
+	}
+	if diff := cmp.Diff(expectedMoved, mod.MovedBlocks, cmp.Comparer(compareTraversal)); diff != "" {
+		t.Fatalf("moved blocks don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_movedInvalid(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+moved {
+  from = lookup(var.map, "key")
+  to   = aws_instance.b
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Invalid address" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if len(mod.MovedBlocks) != 1 {
+		t.Fatalf("expected moved block to be recorded, %d given", len(mod.MovedBlocks))
+	}
+}
+
+func TestLoadModuleFromFile_imports(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+import {
+  to = aws_instance.web
+  id = "i-abcd1234"
+}
+
+import {
+  for_each = {
+    "staging" = "bucket1"
+    "uat"     = "bucket2"
+  }
+  to       = aws_s3_bucket.this[each.key]
+  id       = each.value
+  provider = aws.west
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedImports := []*module.Import{
+		{
+			To: mustTraversal(t, "aws_instance.web"),
+		},
+		{
+			To: mustTraversal(t, "aws_s3_bucket.this"),
+			Provider: module.ProviderRef{
+				LocalName: "aws",
+				Alias:     "west",
+			},
+		},
+	}
+	opts := cmp.Options{
+		cmp.Comparer(compareTraversal),
+		cmpopts.IgnoreFields(module.Import{}, "ID", "ForEach"),
+	}
+	if diff := cmp.Diff(expectedImports, mod.Imports, opts); diff != "" {
+		t.Fatalf("imports don't match: %s", diff)
+	}
+
+	id, _ := mod.Imports[0].ID.Value(nil)
+	if id.AsString() != "i-abcd1234" {
+		t.Fatalf("unexpected import ID: %#v", id)
+	}
+	if mod.Imports[0].ForEach != nil {
+		t.Fatalf("expected no for_each for first import")
+	}
+
+	forEach, _ := mod.Imports[1].ForEach.Value(nil)
+	if forEach.LengthInt() != 2 {
+		t.Fatalf("unexpected for_each: %#v", forEach)
+	}
+}
+
+func TestLoadModuleFromFile_removed(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+removed {
+  from = aws_instance.old
+}
+
+removed {
+  from = module.legacy
+
+  lifecycle {
+    destroy = false
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedRemoved := []*module.Removed{
+		{
+			From:    mustTraversal(t, "aws_instance.old"),
+			Destroy: true,
+		},
+		{
+			From:    mustTraversal(t, "module.legacy"),
+			Destroy: false,
+		},
+	}
+	if diff := cmp.Diff(expectedRemoved, mod.Removed, cmp.Comparer(compareTraversal)); diff != "" {
+		t.Fatalf("removed blocks don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_checks(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+check "health_check" {
+  data "http" "terraform_io" {
+    url = "https://www.terraform.io"
+  }
+
+  assert {
+    condition     = data.http.terraform_io.status_code == 200
+    error_message = "${data.http.terraform_io.url} returned an unhealthy status code"
+  }
+
+  assert {
+    condition     = length(data.http.terraform_io.response_body) > 0
+    error_message = "empty response"
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedChecks := map[string]*module.Check{
+		"health_check": {
+			Name:        "health_check",
+			DataSources: []string{"data.http.terraform_io"},
+			ScopedDataSources: map[string]*module.DataSource{
+				"data.http.terraform_io": {
+					Type:     "http",
+					Name:     "terraform_io",
+					Provider: module.ProviderRef{LocalName: "http"},
+					DeclRange: hcl.Range{
+						Filename: "test.tf",
+						Start:    hcl.Pos{Line: 3, Column: 3, Byte: 26},
+						End:      hcl.Pos{Line: 3, Column: 29, Byte: 52},
+					},
+				},
+			},
+			AssertionCount: 2,
+		},
+	}
+	if diff := cmp.Diff(expectedChecks, mod.Checks, cmpopts.IgnoreFields(module.DataSource{}, "Body")); diff != "" {
+		t.Fatalf("checks don't match: %s", diff)
+	}
+	if len(mod.DataSources) != 0 {
+		t.Fatalf("expected no top-level data sources, %d given", len(mod.DataSources))
+	}
+}
+
+func TestLoadModuleFromFile_moduleVersion(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 3.0"
+}
+
+module "invalid" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "not-a-version"
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != `Unable to parse "invalid" module version` {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+
+	expectedSources := map[string]*module.ModuleSource{
+		"module.vpc": {
+			Name:    "vpc",
+			Source:  "terraform-aws-modules/vpc/aws",
+			Version: "~> 3.0",
+		},
+		"module.invalid": {
+			Name:    "invalid",
+			Source:  "terraform-aws-modules/vpc/aws",
+			Version: "not-a-version",
+		},
+	}
+	if diff := cmp.Diff(expectedSources, mod.ModuleSources, ignoreDeclRanges); diff != "" {
+		t.Fatalf("module sources don't match: %s", diff)
+	}
+}
+
+func TestIsOverrideFile(t *testing.T) {
+	testCases := map[string]bool{
+		"main.tf":                   false,
+		"main.tf.json":              false,
+		"override.tf":               true,
+		"override.tf.json":          true,
+		"versions_override.tf":      true,
+		"versions_override.tf.json": true,
+		"overrides.tf":              false,
+		"override.txt":              false,
+		"nested/dir/override.tf":    true,
+	}
+
+	for filename, expected := range testCases {
+		if isOverrideFile(filename) != expected {
+			t.Errorf("%q: expected %t", filename, expected)
+		}
+	}
+}
+
+func TestLoadModuleFromFile_repetition(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+resource "aws_instance" "counted" {
+  count = 3
+}
+
+resource "aws_instance" "iterated" {
+  for_each = toset(["a", "b"])
+}
+
+data "aws_ami" "counted" {
+  count = var.enabled ? 1 : 0
+}
+
+resource "aws_instance" "single" {}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	counted := mod.Resources["aws_instance.counted"]
+	if !counted.HasCount() || counted.HasForEach() {
+		t.Fatalf("expected only count for %s", counted.MapKey())
+	}
+	countVal, _ := counted.Count.Value(nil)
+	if !countVal.RawEquals(cty.NumberIntVal(3)) {
+		t.Fatalf("unexpected count: %#v", countVal)
+	}
+
+	iterated := mod.Resources["aws_instance.iterated"]
+	if iterated.HasCount() || !iterated.HasForEach() {
+		t.Fatalf("expected only for_each for %s", iterated.MapKey())
+	}
+
+	single := mod.Resources["aws_instance.single"]
+	if single.HasCount() || single.HasForEach() {
+		t.Fatalf("expected neither count nor for_each for %s", single.MapKey())
+	}
+
+	ds := mod.DataSources["data.aws_ami.counted"]
+	if !ds.HasCount() || ds.HasForEach() {
+		t.Fatalf("expected only count for %s", ds.MapKey())
+	}
+}
+
+func TestLoadModuleFromFile_countAndForEach(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+resource "aws_instance" "both" {
+  count    = 2
+  for_each = toset(["a", "b"])
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != `Invalid combination of "count" and "for_each"` {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+}
+
+func TestLoadModuleFromFile_dependsOn(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+resource "aws_instance" "web" {
+  depends_on = [aws_iam_role_policy.example]
+}
+
+data "aws_ami" "web" {
+  depends_on = [aws_instance.web]
+}
+
+module "app" {
+  source     = "./app"
+  depends_on = [aws_instance.web, module.network.vpc_id]
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	opts := cmp.Comparer(compareTraversal)
+
+	expectedResourceDeps := []hcl.Traversal{
+		mustTraversal(t, "aws_iam_role_policy.example"),
+	}
+	if diff := cmp.Diff(expectedResourceDeps, mod.Resources["aws_instance.web"].DependsOn, opts); diff != "" {
+		t.Fatalf("resource dependencies don't match: %s", diff)
+	}
+
+	expectedDataDeps := []hcl.Traversal{
+		mustTraversal(t, "aws_instance.web"),
+	}
+	if diff := cmp.Diff(expectedDataDeps, mod.DataSources["data.aws_ami.web"].DependsOn, opts); diff != "" {
+		t.Fatalf("data source dependencies don't match: %s", diff)
+	}
+
+	expectedModuleDeps := []hcl.Traversal{
+		mustTraversal(t, "aws_instance.web"),
+		mustTraversal(t, "module.network.vpc_id"),
+	}
+	if diff := cmp.Diff(expectedModuleDeps, mod.ModuleSources["module.app"].DependsOn, opts); diff != "" {
+		t.Fatalf("module dependencies don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_dependsOnInvalid(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+resource "aws_instance" "web" {
+  depends_on = [aws_iam_role.example, "aws_iam_role.other"]
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Invalid depends_on reference" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if len(mod.Resources["aws_instance.web"].DependsOn) != 1 {
+		t.Fatalf("expected valid reference to be kept")
+	}
+}
+
+func TestLoadModuleFromFile_moduleProviders(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+module "app" {
+  source = "./app"
+  providers = {
+    aws          = aws
+    aws.replica  = aws.useast
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedProviders := map[string]module.ProviderRef{
+		"aws": {
+			LocalName: "aws",
+		},
+		"aws.replica": {
+			LocalName: "aws",
+			Alias:     "useast",
+		},
+	}
+	if diff := cmp.Diff(expectedProviders, mod.ModuleSources["module.app"].Providers); diff != "" {
+		t.Fatalf("module providers don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_moduleProvidersInvalid(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+module "app" {
+  source = "./app"
+  providers = {
+    aws    = aws.useast
+    google = lookup(var.providers, "google")
+  }
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Invalid provider reference" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+
+	expectedProviders := map[string]module.ProviderRef{
+		"aws": {
+			LocalName: "aws",
+			Alias:     "useast",
+		},
+	}
+	if diff := cmp.Diff(expectedProviders, mod.ModuleSources["module.app"].Providers); diff != "" {
+		t.Fatalf("module providers don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_movedMixedKinds(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+moved {
+  from = module.old
+  to   = aws_instance.b
+}
+
+moved {
+  from = data.aws_ami.a
+  to   = data.aws_ami.b
+}
+`), mod)
+	summaries := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		summaries = append(summaries, diag.Summary)
+	}
+	expectedSummaries := []string{
+		`Invalid "moved" addresses`,
+		"Invalid move endpoint",
+		"Invalid move endpoint",
+	}
+	if diff := cmp.Diff(expectedSummaries, summaries); diff != "" {
+		t.Fatalf("unexpected diagnostics: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_importForms(t *testing.T) {
+	parser := hclparse.NewParser()
+
+	testCases := []struct {
+		filename         string
+		expectedIdentity bool
+	}{
+		{"id.tf", false},
+		{"identity.tf", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			f, diags := parser.ParseHCLFile(filepath.Join("testdata", "imports", tc.filename))
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			mod := newDecodedModule()
+			diags = loadModuleFromFile(f, mod)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			if len(mod.Imports) != 1 {
+				t.Fatalf("expected exactly 1 import, %d given", len(mod.Imports))
+			}
+			imp := mod.Imports[0]
+			if imp.UsesIdentity() != tc.expectedIdentity {
+				t.Fatalf("expected UsesIdentity to return %t", tc.expectedIdentity)
+			}
+			if (imp.ID == nil) == (imp.Identity == nil) {
+				t.Fatalf("expected exactly one of ID and Identity, given: %#v", imp)
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_importIdAndIdentity(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+import {
+  to       = aws_instance.web
+  id       = "i-abcd1234"
+  identity = {
+    id = "i-abcd1234"
+  }
+}
+`), mod)
+
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Conflicting import identification" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+	if mod.Imports[0].UsesIdentity() {
+		t.Fatal("expected id to take precedence over identity")
+	}
+}
+
+func TestLoadModuleFromFile_providerStringReference(t *testing.T) {
+	testCases := []struct {
+		cfg         string
+		key         string
+		expectedRef module.ProviderRef
+	}{
+		{
+			`resource "aws_instance" "web" {
+  provider = "aws.west"
+}`,
+			"aws_instance.web",
+			module.ProviderRef{LocalName: "aws", Alias: "west"},
+		},
+		{
+			`resource "aws_instance" "web" {
+  provider = "aws"
+}`,
+			"aws_instance.web",
+			module.ProviderRef{LocalName: "aws"},
+		},
+		{
+			`resource "google_compute_instance" "vm" {
+  provider = "google-beta.europe"
+}`,
+			"google_compute_instance.vm",
+			module.ProviderRef{LocalName: "google-beta", Alias: "europe"},
+		},
+		{
+			`data "aws_ami" "ubuntu" {
+  provider = "aws.west"
+}`,
+			"data.aws_ami.ubuntu",
+			module.ProviderRef{LocalName: "aws", Alias: "west"},
+		},
+		{
+			`ephemeral "aws_secretsmanager_secret_version" "db" {
+  provider = "aws"
+}`,
+			"ephemeral.aws_secretsmanager_secret_version.db",
+			module.ProviderRef{LocalName: "aws"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.key), func(t *testing.T) {
+			mod := newDecodedModule()
+			diags := loadModuleFromFile(mustParseFile(t, "test.tf", tc.cfg), mod)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			var ref module.ProviderRef
+			switch {
+			case mod.Resources[tc.key] != nil:
+				ref = mod.Resources[tc.key].Provider
+			case mod.DataSources[tc.key] != nil:
+				ref = mod.DataSources[tc.key].Provider
+			case mod.EphemeralResources[tc.key] != nil:
+				ref = mod.EphemeralResources[tc.key].Provider
+			default:
+				t.Fatalf("%q not found", tc.key)
+			}
+			if diff := cmp.Diff(tc.expectedRef, ref); diff != "" {
+				t.Fatalf("unexpected provider reference: %s", diff)
+			}
+
+			// the string form is as explicit as the traversal
+			// and so the provider must not be inferred later
+			if _, ok := mod.ProviderAttrRanges[tc.key]; !ok {
+				t.Fatalf("expected provider attribute range of %q", tc.key)
+			}
+		})
+	}
+}
+
+func TestLoadModuleFromFile_providerStringReferenceInvalid(t *testing.T) {
+	testCases := []string{
+		`""`,
+		`"aws."`,
+		`"aws west"`,
+	}
+
+	for i, expr := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, expr), func(t *testing.T) {
+			mod := newDecodedModule()
+			diags := loadModuleFromFile(mustParseFile(t, "test.tf", fmt.Sprintf(`
+resource "aws_instance" "web" {
+  provider = %s
+}
+`, expr)), mod)
+			if len(diags) != 1 {
+				t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+			}
+			if diags[0].Summary != "Invalid provider reference" {
+				t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package earlydecoder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+// ModuleResolver resolves sources of registry modules to local
+// directories, e.g. from a cache of previously installed modules,
+// such that LoadModuleTreeWithResolver can descend into them
+// without network access
+type ModuleResolver interface {
+	// ResolveRegistry returns the directory containing the module
+	// of the given registry source and version constraint, which
+	// is empty if the module call has no version argument
+	ResolveRegistry(source, version string) (localDir string, err error)
+}
+
+// DefaultIgnoreDirs contains names of directories which LoadModuleTree
+// doesn't descend into by default, such as the .terraform directory
+// containing copies of installed modules
+var DefaultIgnoreDirs = []string{".terraform"}
+
+// TreeOptions represents options for loading a module tree
+type TreeOptions struct {
+	// Resolver resolves registry modules to local directories,
+	// which are otherwise not descended into
+	Resolver ModuleResolver
+
+	// IgnoreDirs contains names of directories which aren't descended
+	// into if a local module source points into them, e.g. to avoid
+	// counting downloaded copies of modules twice.
+	// DefaultIgnoreDirs is used if nil.
+	IgnoreDirs []string
+}
+
+// LoadModuleTree loads the module in the given directory along with
+// all local modules it calls, recursively.
+//
+// A module which (transitively) calls itself is reported as an error
+// on the module call closing the cycle, which isn't followed further.
+// Diagnostics of all loaded modules are returned together.
+//
+// Calls of registry and remote modules are recorded
+// in ModuleTree.Unresolved but not descended into.
+// Local modules within DefaultIgnoreDirs are skipped.
+func LoadModuleTree(rootDir string) (*module.ModuleTree, hcl.Diagnostics) {
+	return LoadModuleTreeWithOptions(rootDir, TreeOptions{})
+}
+
+// LoadModuleTreeWithResolver is like LoadModuleTree, but also descends
+// into registry modules which the given resolver resolves. Failures
+// to resolve a module are reported as warnings.
+func LoadModuleTreeWithResolver(rootDir string, resolver ModuleResolver) (*module.ModuleTree, hcl.Diagnostics) {
+	return LoadModuleTreeWithOptions(rootDir, TreeOptions{Resolver: resolver})
+}
+
+// LoadModuleTreeWithOptions is like LoadModuleTree,
+// with the behaviour customized via the given options
+func LoadModuleTreeWithOptions(rootDir string, opts TreeOptions) (*module.ModuleTree, hcl.Diagnostics) {
+	if opts.IgnoreDirs == nil {
+		opts.IgnoreDirs = DefaultIgnoreDirs
+	}
+	return loadModuleTree(filepath.Clean(rootDir), opts, make(map[string]bool, 0))
+}
+
+// loadModuleTree loads the tree of the module in the given directory,
+// where ancestors contains cleaned paths of the modules being loaded
+// further up the tree, i.e. those which would form a cycle if called
+func loadModuleTree(dir string, opts TreeOptions, ancestors map[string]bool) (*module.ModuleTree, hcl.Diagnostics) {
+	meta, diags, err := LoadModuleFromDir(dir)
+	if err != nil && meta == nil {
+		return nil, diags
+	}
+
+	tree := &module.ModuleTree{
+		Path:       dir,
+		Meta:       meta,
+		Children:   make(map[string]*module.ModuleTree, 0),
+		Unresolved: make(map[string]*module.ModuleSource, 0),
+	}
+
+	ancestors[dir] = true
+	defer delete(ancestors, dir)
+
+	for _, ms := range meta.SortedModuleSources() {
+		childDir, ok := ms.ResolveLocal(dir)
+		if ok && isInIgnoredDir(ms.Source, opts.IgnoreDirs) {
+			continue
+		}
+		if !ok {
+			var rDiags hcl.Diagnostics
+			childDir, rDiags = resolveRegistryModule(opts.Resolver, ms)
+			diags = append(diags, rDiags...)
+			if childDir == "" {
+				tree.Unresolved[ms.Name] = ms
+				continue
+			}
+		}
+
+		if ancestors[childDir] {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Cyclic module reference",
+				Detail: fmt.Sprintf("Module %q in %s calls %s, which is already being loaded "+
+					"as one of its ancestors, so the call would never terminate.", ms.Name, dir, childDir),
+				Subject: ms.DeclRange.Ptr(),
+			})
+			continue
+		}
+
+		child, childDiags := loadModuleTree(childDir, opts, ancestors)
+		diags = append(diags, childDiags...)
+		if child != nil {
+			tree.Children[ms.Name] = child
+		}
+	}
+
+	return tree, diags
+}
+
+// resolveRegistryModule returns the cleaned local directory of the given
+// registry module as resolved by the resolver, or an empty string if
+// the module isn't a registry module or there's no resolver
+func resolveRegistryModule(resolver ModuleResolver, ms *module.ModuleSource) (string, hcl.Diagnostics) {
+	if resolver == nil || ms.Kind() != module.RegistryModuleSourceKind {
+		return "", nil
+	}
+
+	localDir, err := resolver.ResolveRegistry(ms.Source, ms.Version)
+	if err != nil {
+		return "", hcl.Diagnostics{
+			{
+				Severity: hcl.DiagWarning,
+				Summary:  "Failed to resolve module",
+				Detail:   fmt.Sprintf("The registry module %q of module call %q could not be resolved: %s", ms.Source, ms.Name, err),
+				Subject:  ms.DeclRange.Ptr(),
+			},
+		}
+	}
+	if localDir == "" {
+		return "", nil
+	}
+	return filepath.Clean(localDir), nil
+}
+
+// isInIgnoredDir returns true if any directory of the given
+// local module source has one of the ignored names
+func isInIgnoredDir(source string, ignoreDirs []string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(source), "/") {
+		for _, name := range ignoreDirs {
+			if part == name {
+				return true
+			}
+		}
+	}
+	return false
+}
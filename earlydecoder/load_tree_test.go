@@ -0,0 +1,150 @@
+package earlydecoder
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestLoadModuleTree(t *testing.T) {
+	root := filepath.Join("testdata", "local-modules")
+
+	tree, diags := LoadModuleTree(root)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Failed to read module directory" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+
+	// the missing and non-local modules are left out
+	names := make([]string, 0)
+	for name := range tree.Children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if diff := cmp.Diff([]string{"empty", "valid"}, names); diff != "" {
+		t.Fatalf("unexpected child modules: %s", diff)
+	}
+
+	if _, ok := tree.Children["registry"]; ok {
+		t.Fatal("expected registry module not to be loaded without a resolver")
+	}
+	if _, ok := tree.Unresolved["registry"]; !ok || len(tree.Unresolved) != 1 {
+		t.Fatalf("expected registry module to be recorded as unresolved, given: %#v", tree.Unresolved)
+	}
+
+	if tree.Path != root {
+		t.Fatalf("unexpected root path: %q", tree.Path)
+	}
+	valid, ok := tree.Children["valid"]
+	if !ok {
+		t.Fatalf("expected valid child module, given: %#v", tree.Children)
+	}
+	if expectedPath := filepath.Join(root, "modules", "valid"); valid.Path != expectedPath {
+		t.Fatalf("expected child path %q, given %q", expectedPath, valid.Path)
+	}
+}
+
+func TestLoadModuleTree_cycle(t *testing.T) {
+	root := filepath.Join("testdata", "module-cycle")
+
+	tree, diags := LoadModuleTree(root)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+
+	cycleDir := filepath.Join(root, "modules", "b")
+	expectedDiag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Cyclic module reference",
+		Detail: `Module "a" in ` + cycleDir + ` calls ` + filepath.Join(root, "modules", "a") +
+			`, which is already being loaded as one of its ancestors, so the call would never terminate.`,
+		Subject: &hcl.Range{
+			Filename: filepath.Join(cycleDir, "main.tf"),
+			Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+			End:      hcl.Pos{Line: 1, Column: 11, Byte: 10},
+		},
+	}
+	if diff := cmp.Diff(expectedDiag, diags[0]); diff != "" {
+		t.Fatalf("unexpected diagnostic: %s", diff)
+	}
+
+	b := tree.Children["a"].Children["b"]
+	if len(b.Children) != 0 {
+		t.Fatalf("expected the cycle not to be followed, given: %#v", b.Children)
+	}
+}
+
+// stubResolver resolves registry sources via a fixed map,
+// failing for any sources not in the map
+type stubResolver map[string]string
+
+func (r stubResolver) ResolveRegistry(source, version string) (string, error) {
+	if dir, ok := r[source]; ok {
+		return dir, nil
+	}
+	return "", errors.New("not cached")
+}
+
+func TestLoadModuleTreeWithResolver(t *testing.T) {
+	root := filepath.Join("testdata", "local-modules")
+	cachedDir := filepath.Join(root, "modules", "valid")
+	resolver := stubResolver{
+		"hashicorp/consul/aws": cachedDir,
+	}
+
+	tree, diags := LoadModuleTreeWithResolver(root, resolver)
+	if len(diags) != 1 || diags[0].Summary != "Failed to read module directory" {
+		t.Fatalf("expected only the missing module to be reported, given: %s", diags)
+	}
+
+	registry, ok := tree.Children["registry"]
+	if !ok {
+		t.Fatalf("expected registry module to be loaded, given: %#v", tree.Children)
+	}
+	if registry.Path != cachedDir {
+		t.Fatalf("expected path %q, given %q", cachedDir, registry.Path)
+	}
+	if len(tree.Unresolved) != 0 {
+		t.Fatalf("expected no unresolved modules, given: %#v", tree.Unresolved)
+	}
+
+	tree, diags = LoadModuleTreeWithResolver(root, stubResolver{})
+	summaries := make([]string, 0)
+	for _, diag := range diags {
+		summaries = append(summaries, diag.Summary)
+	}
+	sort.Strings(summaries)
+	expectedSummaries := []string{"Failed to read module directory", "Failed to resolve module"}
+	if diff := cmp.Diff(expectedSummaries, summaries); diff != "" {
+		t.Fatalf("unexpected diagnostics: %s", diff)
+	}
+	if _, ok := tree.Unresolved["registry"]; !ok {
+		t.Fatalf("expected registry module to be recorded as unresolved, given: %#v", tree.Unresolved)
+	}
+}
+
+func TestLoadModuleTree_ignoreDirs(t *testing.T) {
+	root := filepath.Join("testdata", "ignore-dirs")
+
+	tree, diags := LoadModuleTree(root)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if _, ok := tree.Children["network_copy"]; ok || len(tree.Children) != 1 {
+		t.Fatalf("expected only the network module to be loaded, given: %#v", tree.Children)
+	}
+
+	tree, diags = LoadModuleTreeWithOptions(root, TreeOptions{IgnoreDirs: []string{}})
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if _, ok := tree.Children["network_copy"]; !ok {
+		t.Fatalf("expected the copy to be loaded without ignored dirs, given: %#v", tree.Children)
+	}
+}
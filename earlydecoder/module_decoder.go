@@ -0,0 +1,345 @@
+package earlydecoder
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+// ModuleDecoder decodes a module incrementally, keeping the decoded
+// contents of each file, such that only files which changed
+// need to be decoded again when the module is updated.
+type ModuleDecoder struct {
+	path  string
+	files map[string]*decodedFile
+}
+
+// decodedFile represents the decoded contents of a single file
+type decodedFile struct {
+	mod   *decodedModule
+	diags hcl.Diagnostics
+}
+
+func NewModuleDecoder(path string) *ModuleDecoder {
+	return &ModuleDecoder{
+		path:  path,
+		files: make(map[string]*decodedFile, 0),
+	}
+}
+
+// UpdateFile decodes the given file, replacing any contents
+// previously decoded from the file of the same name.
+//
+// The file may be the partial result of parsing a file with syntax
+// errors. A nil file (or body) is treated as an empty file.
+func (d *ModuleDecoder) UpdateFile(name string, file *hcl.File) {
+	if file == nil || file.Body == nil {
+		d.files[name] = &decodedFile{
+			mod: newDecodedModule(),
+		}
+		return
+	}
+
+	mod := newDecodedModuleWithCapacity(countBlocks(file))
+	diags := loadModuleFromFile(file, mod)
+	d.files[name] = &decodedFile{
+		mod:   mod,
+		diags: diags,
+	}
+}
+
+// RemoveFile removes any contents previously decoded
+// from the file of the given name
+func (d *ModuleDecoder) RemoveFile(name string) {
+	delete(d.files, name)
+}
+
+// Meta merges the decoded contents of all files
+// and returns the resulting module metadata
+func (d *ModuleDecoder) Meta() (*module.Meta, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	filenames := make([]string, 0, len(d.files))
+	for name := range d.files {
+		filenames = append(filenames, name)
+	}
+	primaryFiles, overrideFiles := sortFilenames(filenames)
+
+	mod := newDecodedModuleWithCapacity(d.blockCounts())
+	for _, filename := range primaryFiles {
+		f := d.files[filename]
+		diags = append(diags, f.diags...)
+		diags = append(diags, mergeFileModule(mod, f.mod)...)
+	}
+
+	// Override files are merged on top
+	// of the primary files in lexical order
+	for _, filename := range overrideFiles {
+		f := d.files[filename]
+		diags = append(diags, f.diags...)
+		diags = append(diags, mergeOverrideModule(mod, f.mod)...)
+	}
+
+	meta, mDiags := buildMeta(d.path, mod)
+	diags = append(diags, mDiags...)
+	meta.Diagnostics = diags
+
+	return meta, diags
+}
+
+// blockCounts returns the number of decoded blocks
+// of each type across all files
+func (d *ModuleDecoder) blockCounts() map[string]int {
+	counts := make(map[string]int, 0)
+	for _, f := range d.files {
+		counts["provider"] += len(f.mod.ProviderConfigs)
+		counts["resource"] += len(f.mod.Resources)
+		counts["data"] += len(f.mod.DataSources)
+		counts["ephemeral"] += len(f.mod.EphemeralResources)
+		counts["module"] += len(f.mod.ModuleSources)
+		counts["variable"] += len(f.mod.Variables)
+		counts["output"] += len(f.mod.Outputs)
+		counts["moved"] += len(f.mod.MovedBlocks)
+		counts["import"] += len(f.mod.Imports)
+		counts["removed"] += len(f.mod.Removed)
+		counts["check"] += len(f.mod.Checks)
+	}
+	return counts
+}
+
+// mergeFileModule merges the module decoded from a single primary file
+// into the base module, reporting any objects which were already
+// declared in other files.
+//
+// Objects are copied before they're inserted or modified,
+// so that the file module itself is left intact.
+func mergeFileModule(base, file *decodedModule) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	base.RequiredCore = append(base.RequiredCore, file.RequiredCore...)
+	base.Experiments = append(base.Experiments, file.Experiments...)
+
+	for name, req := range file.ProviderRequirements {
+		baseReq, exists := base.ProviderRequirements[name]
+		if !exists {
+			base.ProviderRequirements[name] = copyProviderRequirement(req)
+			continue
+		}
+		if req.Source != "" {
+			if baseReq.Source != "" && baseReq.MapKey(name) != req.MapKey(name) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple provider source attributes",
+					Detail:   fmt.Sprintf("Found multiple source attributes for provider %s: %q, %q", name, baseReq.Source, req.Source),
+					Subject:  req.DeclRange.Ptr(),
+				})
+			} else {
+				baseReq.Source = req.Source
+			}
+		}
+		baseReq.VersionConstraints = append(baseReq.VersionConstraints, req.VersionConstraints...)
+		baseReq.ConfigurationAliases = append(baseReq.ConfigurationAliases, req.ConfigurationAliases...)
+		if len(baseReq.DeclRanges) == 0 {
+			baseReq.DeclRange = req.DeclRange
+		}
+		baseReq.DeclRanges = append(baseReq.DeclRanges, req.DeclRanges...)
+	}
+
+	for key, cfg := range file.ProviderConfigs {
+		if _, exists := base.ProviderConfigs[key]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple provider configurations",
+				Detail:   fmt.Sprintf("Found multiple configurations of provider %q", key),
+				Subject:  cfg.DeclRange.Ptr(),
+			})
+		}
+		base.ProviderConfigs[key] = cfg
+	}
+
+	for key, r := range file.Resources {
+		if _, exists := base.Resources[key]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple resource definitions",
+				Detail:   fmt.Sprintf("Found multiple definitions of resource %q", key),
+				Subject:  r.DeclRange.Ptr(),
+			})
+		}
+		rCopy := *r
+		base.Resources[key] = &rCopy
+		mergeProviderAttrRange(base, file, key)
+	}
+
+	for key, ds := range file.DataSources {
+		if _, exists := base.DataSources[key]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple data source definitions",
+				Detail:   fmt.Sprintf("Found multiple definitions of data source %q", key),
+				Subject:  ds.DeclRange.Ptr(),
+			})
+		}
+		dsCopy := *ds
+		base.DataSources[key] = &dsCopy
+		mergeProviderAttrRange(base, file, key)
+	}
+
+	for key, er := range file.EphemeralResources {
+		if _, exists := base.EphemeralResources[key]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple ephemeral resource definitions",
+				Detail:   fmt.Sprintf("Found multiple definitions of ephemeral resource %q", key),
+				Subject:  er.DeclRange.Ptr(),
+			})
+		}
+		erCopy := *er
+		base.EphemeralResources[key] = &erCopy
+		mergeProviderAttrRange(base, file, key)
+	}
+
+	for key, ms := range file.ModuleSources {
+		msCopy := *ms
+		if origMod, exists := base.ModuleSources[key]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple module definitions",
+				Detail:   fmt.Sprintf("Found multiple definitions of module %q", ms.Name),
+				Subject:  ms.DeclRange.Ptr(),
+			})
+			if msCopy.Source == "" {
+				msCopy.Source = origMod.Source
+			}
+		}
+		base.ModuleSources[key] = &msCopy
+	}
+
+	for name, v := range file.Variables {
+		base.Variables[name] = v
+	}
+
+	for name, o := range file.Outputs {
+		if _, exists := base.Outputs[name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple output definitions",
+				Detail:   fmt.Sprintf("Found multiple definitions of output %q", name),
+				Subject:  o.DeclRange.Ptr(),
+			})
+		}
+		base.Outputs[name] = o
+	}
+
+	for name, attr := range file.Locals {
+		if _, exists := base.Locals[name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate local value definition",
+				Detail:   fmt.Sprintf("Found multiple definitions of local value %q", name),
+				Subject:  attr.NameRange.Ptr(),
+			})
+			continue
+		}
+		base.Locals[name] = attr
+	}
+
+	if file.Backend != nil {
+		switch {
+		case base.Backend != nil:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple backend definitions",
+				Detail:   fmt.Sprintf("Found multiple backend definitions: %q, %q", base.Backend.Type, file.Backend.Type),
+				Subject:  file.BackendRange.Ptr(),
+			})
+		case base.Cloud != nil:
+			diags = append(diags, cloudBackendConflictDiagnostic(file.BackendRange))
+		default:
+			base.Backend, base.BackendRange = file.Backend, file.BackendRange
+		}
+	}
+
+	if file.Cloud != nil {
+		switch {
+		case base.Cloud != nil:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple cloud definitions",
+				Detail:   "Found multiple cloud blocks, only one is allowed",
+				Subject:  file.CloudRange.Ptr(),
+			})
+		case base.Backend != nil:
+			diags = append(diags, cloudBackendConflictDiagnostic(file.CloudRange))
+		default:
+			base.Cloud, base.CloudRange = file.Cloud, file.CloudRange
+		}
+	}
+
+	for name, pm := range file.ProviderMetas {
+		if _, exists := base.ProviderMetas[name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple provider_meta definitions",
+				Detail:   fmt.Sprintf("Found multiple provider_meta blocks for provider %q", name),
+				Subject:  pm.DeclRange.Ptr(),
+			})
+			continue
+		}
+		base.ProviderMetas[name] = pm
+	}
+
+	base.MovedBlocks = append(base.MovedBlocks, file.MovedBlocks...)
+	base.Imports = append(base.Imports, file.Imports...)
+	base.Removed = append(base.Removed, file.Removed...)
+
+	for name, c := range file.Checks {
+		cCopy := *c
+		cCopy.ScopedDataSources = make(map[string]*module.DataSource, len(c.ScopedDataSources))
+		for key, ds := range c.ScopedDataSources {
+			dsCopy := *ds
+			cCopy.ScopedDataSources[key] = &dsCopy
+			mergeProviderAttrRange(base, file, checkScopedKey(name, key))
+		}
+		base.Checks[name] = &cCopy
+	}
+
+	return diags
+}
+
+// mergeProviderAttrRange replaces the range of the provider attribute
+// of the resource, data source or ephemeral resource of the given key in the base module
+func mergeProviderAttrRange(base, file *decodedModule, key string) {
+	if rng, ok := file.ProviderAttrRanges[key]; ok {
+		base.ProviderAttrRanges[key] = rng
+		return
+	}
+	delete(base.ProviderAttrRanges, key)
+}
+
+// checkScopedKey returns the key of a data source scoped to the check
+// of the given name, such that it doesn't collide with a top-level
+// data source of the same map key, e.g. check.health.data.http.api
+func checkScopedKey(checkName, key string) string {
+	return fmt.Sprintf("check.%s.%s", checkName, key)
+}
+
+// copyProviderRequirement returns a copy of the given requirement
+// which can be appended to without modifying the original
+func copyProviderRequirement(req *providerRequirement) *providerRequirement {
+	reqCopy := *req
+	if req.VersionConstraints != nil {
+		reqCopy.VersionConstraints = make([]string, len(req.VersionConstraints))
+		copy(reqCopy.VersionConstraints, req.VersionConstraints)
+	}
+	if req.ConfigurationAliases != nil {
+		reqCopy.ConfigurationAliases = make([]module.ProviderRef, len(req.ConfigurationAliases))
+		copy(reqCopy.ConfigurationAliases, req.ConfigurationAliases)
+	}
+	if req.DeclRanges != nil {
+		reqCopy.DeclRanges = make([]hcl.Range, len(req.DeclRanges))
+		copy(reqCopy.DeclRanges, req.DeclRanges)
+	}
+	return &reqCopy
+}
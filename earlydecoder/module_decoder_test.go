@@ -0,0 +1,228 @@
+package earlydecoder
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-schema/module"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestModuleDecoder_UpdateFile(t *testing.T) {
+	d := NewModuleDecoder("path")
+	d.UpdateFile("main.tf", mustParseFile(t, "main.tf", `
+resource "aws_instance" "web" {
+}
+`))
+	d.UpdateFile("other.tf", mustParseFile(t, "other.tf", `
+resource "aws_instance" "db" {
+}
+`))
+
+	meta, diags := d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	expectResourceKeys(t, meta.Resources, []string{"aws_instance.db", "aws_instance.web"})
+
+	// add a resource
+	d.UpdateFile("main.tf", mustParseFile(t, "main.tf", `
+resource "aws_instance" "web" {
+}
+resource "aws_eip" "web" {
+}
+`))
+	meta, diags = d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	expectResourceKeys(t, meta.Resources, []string{"aws_eip.web", "aws_instance.db", "aws_instance.web"})
+
+	// modify a resource
+	d.UpdateFile("main.tf", mustParseFile(t, "main.tf", `
+resource "aws_instance" "web" {
+  count = 2
+}
+resource "aws_eip" "web" {
+}
+`))
+	meta, diags = d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if meta.Resources["aws_instance.web"].Count == nil {
+		t.Fatal("expected updated count of aws_instance.web")
+	}
+	if meta.Resources["aws_instance.db"].Count != nil {
+		t.Fatal("expected aws_instance.db to remain unchanged")
+	}
+
+	// delete a resource
+	d.UpdateFile("main.tf", mustParseFile(t, "main.tf", `
+resource "aws_eip" "web" {
+}
+`))
+	meta, diags = d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	expectResourceKeys(t, meta.Resources, []string{"aws_eip.web", "aws_instance.db"})
+
+	// remove a whole file
+	d.RemoveFile("other.tf")
+	meta, diags = d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	expectResourceKeys(t, meta.Resources, []string{"aws_eip.web"})
+}
+
+func TestModuleDecoder_duplicatesAcrossFiles(t *testing.T) {
+	d := NewModuleDecoder("path")
+	d.UpdateFile("main.tf", mustParseFile(t, "main.tf", `
+resource "aws_instance" "web" {
+}
+`))
+	d.UpdateFile("other.tf", mustParseFile(t, "other.tf", `
+resource "aws_instance" "web" {
+}
+`))
+
+	_, diags := d.Meta()
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Multiple resource definitions" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+	if diags[0].Subject.Filename != "other.tf" {
+		t.Fatalf("unexpected diagnostic filename: %q", diags[0].Subject.Filename)
+	}
+
+	// fixing the duplicate in one file resolves it
+	d.UpdateFile("other.tf", mustParseFile(t, "other.tf", `
+resource "aws_instance" "db" {
+}
+`))
+	_, diags = d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+}
+
+func TestModuleDecoder_overrideKeepsFilesIntact(t *testing.T) {
+	d := NewModuleDecoder("path")
+	d.UpdateFile("main.tf", mustParseFile(t, "main.tf", `
+module "vpc" {
+  source = "./vpc"
+}
+
+variable "name" {
+  default = "web"
+}
+`))
+	d.UpdateFile("override.tf", mustParseFile(t, "override.tf", `
+module "vpc" {
+  source = "./vpc-override"
+}
+
+variable "name" {
+  default = "api"
+}
+`))
+
+	meta, diags := d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if meta.ModuleSources["module.vpc"].Source != "./vpc-override" {
+		t.Fatalf("unexpected overridden source: %q", meta.ModuleSources["module.vpc"].Source)
+	}
+	if !meta.Variables["name"].DefaultValue.RawEquals(cty.StringVal("api")) {
+		t.Fatalf("unexpected overridden default: %#v", meta.Variables["name"].DefaultValue)
+	}
+
+	d.RemoveFile("override.tf")
+	meta, diags = d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if meta.ModuleSources["module.vpc"].Source != "./vpc" {
+		t.Fatalf("unexpected source after removing override: %q", meta.ModuleSources["module.vpc"].Source)
+	}
+	if !meta.Variables["name"].DefaultValue.RawEquals(cty.StringVal("web")) {
+		t.Fatalf("unexpected default after removing override: %#v", meta.Variables["name"].DefaultValue)
+	}
+}
+
+func TestModuleDecoder_checkScopedDataSourceProvider(t *testing.T) {
+	d := NewModuleDecoder("path")
+	d.UpdateFile("a.tf", mustParseFile(t, "a.tf", `
+terraform {
+  required_providers {
+    http = {
+      source = "hashicorp/http"
+    }
+    other = {
+      source = "example/other"
+    }
+  }
+}
+
+provider "other" {
+  alias = "x"
+}
+
+data "http_get" "foo" {
+  provider = other.x
+}
+`))
+	d.UpdateFile("b.tf", mustParseFile(t, "b.tf", `
+check "c" {
+  data "http_get" "foo" {}
+}
+`))
+
+	meta, diags := d.Meta()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedRef := module.ProviderRef{LocalName: "other", Alias: "x"}
+	if ref := meta.DataSources["data.http_get.foo"].Provider; ref != expectedRef {
+		t.Fatalf("expected top-level provider %s, given: %s", expectedRef, ref)
+	}
+	expectedRef = module.ProviderRef{LocalName: "http"}
+	if ref := meta.Checks["c"].ScopedDataSources["data.http_get.foo"].Provider; ref != expectedRef {
+		t.Fatalf("expected check-scoped provider %s, given: %s", expectedRef, ref)
+	}
+}
+
+func expectResourceKeys(t *testing.T, resources map[string]*module.Resource, expectedKeys []string) {
+	t.Helper()
+
+	keys := make([]string, 0, len(resources))
+	for key := range resources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if diff := cmp.Diff(expectedKeys, keys); diff != "" {
+		t.Fatalf("unexpected resources: %s", diff)
+	}
+}
+
+func BenchmarkModuleDecoder_Meta(b *testing.B) {
+	d := NewModuleDecoder("path")
+	d.UpdateFile("main.tf", mustParseFile(b, "main.tf", syntheticModuleConfig(2000)))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, diags := d.Meta()
+		if len(diags) > 0 {
+			b.Fatal(diags)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package earlydecoder
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+// sortFilenames splits filenames into primary and override files
+// and sorts each of them lexically
+func sortFilenames(filenames []string) (primary []string, override []string) {
+	primary = make([]string, 0)
+	override = make([]string, 0)
+
+	for _, filename := range filenames {
+		if isOverrideFile(filename) {
+			override = append(override, filename)
+			continue
+		}
+		primary = append(primary, filename)
+	}
+
+	sort.Strings(primary)
+	sort.Strings(override)
+
+	return primary, override
+}
+
+// isOverrideFile returns true if the given filename
+// represents an override file, such as override.tf
+// or main_override.tf.json
+func isOverrideFile(filename string) bool {
+	name := filepath.Base(filename)
+	switch {
+	case strings.HasSuffix(name, ".tf.json"):
+		name = strings.TrimSuffix(name, ".tf.json")
+	case strings.HasSuffix(name, ".tf"):
+		name = strings.TrimSuffix(name, ".tf")
+	default:
+		return false
+	}
+
+	return name == "override" || strings.HasSuffix(name, "_override")
+}
+
+// mergeOverrideModule merges the decoded override module into the base
+// module, replacing individual arguments rather than appending them,
+// in line with Terraform's override semantics.
+//
+// Like in Terraform, blocks other than terraform blocks can only
+// override blocks declared in primary files, so overrides missing
+// a base block are reported and otherwise ignored.
+//
+// Objects are copied before they're inserted or modified,
+// so that the override module itself is left intact.
+func mergeOverrideModule(base, override *decodedModule) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	if len(override.RequiredCore) > 0 {
+		base.RequiredCore = override.RequiredCore
+	}
+	if len(override.Experiments) > 0 {
+		base.Experiments = override.Experiments
+	}
+
+	for name, req := range override.ProviderRequirements {
+		baseReq, exists := base.ProviderRequirements[name]
+		if !exists {
+			base.ProviderRequirements[name] = copyProviderRequirement(req)
+			continue
+		}
+		if req.Source != "" {
+			baseReq.Source = req.Source
+		}
+		if len(req.VersionConstraints) > 0 {
+			baseReq.VersionConstraints = req.VersionConstraints
+		}
+		if len(req.ConfigurationAliases) > 0 {
+			baseReq.ConfigurationAliases = req.ConfigurationAliases
+		}
+		if len(baseReq.DeclRanges) == 0 {
+			baseReq.DeclRange = req.DeclRange
+		}
+		baseReq.DeclRanges = append(baseReq.DeclRanges, req.DeclRanges...)
+	}
+
+	for key, cfg := range override.ProviderConfigs {
+		if _, exists := base.ProviderConfigs[key]; !exists {
+			diags = append(diags, missingBaseBlockDiagnostic("provider configuration", key, cfg.DeclRange))
+			continue
+		}
+		base.ProviderConfigs[key] = cfg
+	}
+
+	for key, ms := range override.ModuleSources {
+		baseMs, exists := base.ModuleSources[key]
+		if !exists {
+			diags = append(diags, missingBaseBlockDiagnostic("module call", ms.Name, ms.DeclRange))
+			continue
+		}
+		attrs := override.DeclaredAttributes[key]
+		if attrs["source"] != nil {
+			baseMs.Source = ms.Source
+		}
+		if attrs["version"] != nil {
+			baseMs.Version = ms.Version
+		}
+		if attrs["providers"] != nil {
+			baseMs.Providers = ms.Providers
+		}
+		if attrs["depends_on"] != nil {
+			baseMs.DependsOn = ms.DependsOn
+		}
+	}
+
+	for name, attr := range override.Locals {
+		if _, exists := base.Locals[name]; !exists {
+			diags = append(diags, missingBaseBlockDiagnostic("local value", name, attr.NameRange))
+			continue
+		}
+		base.Locals[name] = attr
+	}
+
+	for name, pm := range override.ProviderMetas {
+		base.ProviderMetas[name] = pm
+	}
+
+	if override.Backend != nil {
+		base.Backend, base.BackendRange = override.Backend, override.BackendRange
+		base.Cloud = nil
+	}
+	if override.Cloud != nil {
+		base.Cloud, base.CloudRange = override.Cloud, override.CloudRange
+		base.Backend = nil
+	}
+
+	// Resources, data sources, ephemeral resources and module calls
+	// of the base module are already copies, so their arguments can be
+	// replaced in place. Other arguments of the body are not merged.
+	for key, r := range override.Resources {
+		baseR, exists := base.Resources[key]
+		if !exists {
+			diags = append(diags, missingBaseBlockDiagnostic("resource", key, r.DeclRange))
+			continue
+		}
+		attrs := override.DeclaredAttributes[key]
+		if overrideProviderAttr(base, override, key) {
+			baseR.Provider = r.Provider
+		}
+		if attrs["count"] != nil {
+			baseR.Count = r.Count
+		}
+		if attrs["for_each"] != nil {
+			baseR.ForEach = r.ForEach
+		}
+		if attrs["depends_on"] != nil {
+			baseR.DependsOn = r.DependsOn
+		}
+		baseR.Lifecycle = mergeOverrideLifecycle(baseR.Lifecycle, r.Lifecycle, override.LifecycleAttributes[key])
+		if r.Provisioners != nil {
+			baseR.Provisioners = r.Provisioners
+		}
+		if r.HasConnection {
+			baseR.HasConnection = true
+		}
+	}
+	for key, ds := range override.DataSources {
+		baseDs, exists := base.DataSources[key]
+		if !exists {
+			diags = append(diags, missingBaseBlockDiagnostic("data source", key, ds.DeclRange))
+			continue
+		}
+		attrs := override.DeclaredAttributes[key]
+		if overrideProviderAttr(base, override, key) {
+			baseDs.Provider = ds.Provider
+		}
+		if attrs["count"] != nil {
+			baseDs.Count = ds.Count
+		}
+		if attrs["for_each"] != nil {
+			baseDs.ForEach = ds.ForEach
+		}
+		if attrs["depends_on"] != nil {
+			baseDs.DependsOn = ds.DependsOn
+		}
+		baseDs.Lifecycle = mergeOverrideLifecycle(baseDs.Lifecycle, ds.Lifecycle, override.LifecycleAttributes[key])
+	}
+	for key, er := range override.EphemeralResources {
+		baseEr, exists := base.EphemeralResources[key]
+		if !exists {
+			diags = append(diags, missingBaseBlockDiagnostic("ephemeral resource", key, er.DeclRange))
+			continue
+		}
+		attrs := override.DeclaredAttributes[key]
+		if overrideProviderAttr(base, override, key) {
+			baseEr.Provider = er.Provider
+		}
+		if attrs["count"] != nil {
+			baseEr.Count = er.Count
+		}
+		if attrs["for_each"] != nil {
+			baseEr.ForEach = er.ForEach
+		}
+		if attrs["depends_on"] != nil {
+			baseEr.DependsOn = er.DependsOn
+		}
+	}
+
+	// Variables and outputs are shared with the files they were
+	// decoded from, so they're copied before being modified
+	for name, v := range override.Variables {
+		baseV, exists := base.Variables[name]
+		if !exists {
+			diags = append(diags, missingBaseBlockDiagnostic("variable", name, v.DeclRange))
+			continue
+		}
+		attrs := override.DeclaredAttributes[v.MapKey()]
+		vCopy := *baseV
+		if attrs["description"] != nil {
+			vCopy.Description = v.Description
+		}
+		if attrs["type"] != nil {
+			vCopy.Type = v.Type
+		}
+		if attrs["default"] != nil {
+			vCopy.DefaultValue = v.DefaultValue
+		}
+		if attrs["sensitive"] != nil {
+			vCopy.IsSensitive = v.IsSensitive
+		}
+		if attrs["ephemeral"] != nil {
+			vCopy.Ephemeral = v.Ephemeral
+		}
+		if attrs["nullable"] != nil {
+			vCopy.IsNullable = v.IsNullable
+		}
+		if v.Validations != nil {
+			vCopy.Validations = v.Validations
+		}
+		base.Variables[name] = &vCopy
+	}
+	for name, o := range override.Outputs {
+		baseO, exists := base.Outputs[name]
+		if !exists {
+			diags = append(diags, missingBaseBlockDiagnostic("output", name, o.DeclRange))
+			continue
+		}
+		attrs := override.DeclaredAttributes[o.MapKey()]
+		oCopy := *baseO
+		if attrs["description"] != nil {
+			oCopy.Description = o.Description
+		}
+		if attrs["value"] != nil {
+			oCopy.Value = o.Value
+		}
+		if attrs["sensitive"] != nil {
+			oCopy.IsSensitive = o.IsSensitive
+		}
+		if attrs["ephemeral"] != nil {
+			oCopy.Ephemeral = o.Ephemeral
+		}
+		if attrs["depends_on"] != nil {
+			oCopy.DependsOn = o.DependsOn
+		}
+		base.Outputs[name] = &oCopy
+	}
+
+	return diags
+}
+
+// mergeOverrideLifecycle returns a copy of the base lifecycle block with
+// the declared attributes and any conditions of the override replaced
+func mergeOverrideLifecycle(base, override *module.Lifecycle, attrs hcl.Attributes) *module.Lifecycle {
+	if override == nil {
+		return base
+	}
+	if base == nil {
+		base = &module.Lifecycle{}
+	}
+
+	merged := *base
+	if attrs["create_before_destroy"] != nil {
+		merged.CreateBeforeDestroy = override.CreateBeforeDestroy
+	}
+	if attrs["prevent_destroy"] != nil {
+		merged.PreventDestroy = override.PreventDestroy
+	}
+	if attrs["ignore_changes"] != nil {
+		merged.IgnoreChanges = override.IgnoreChanges
+		merged.IgnoreAllChanges = override.IgnoreAllChanges
+	}
+	if attrs["replace_triggered_by"] != nil {
+		merged.ReplaceTriggeredBy = override.ReplaceTriggeredBy
+	}
+	if override.Preconditions != nil {
+		merged.Preconditions = override.Preconditions
+	}
+	if override.Postconditions != nil {
+		merged.Postconditions = override.Postconditions
+	}
+	return &merged
+}
+
+// overrideProviderAttr replaces the range of the provider attribute
+// of the object of the given key in the base module and returns true
+// if the override declares the attribute
+func overrideProviderAttr(base, override *decodedModule, key string) bool {
+	rng, ok := override.ProviderAttrRanges[key]
+	if ok {
+		base.ProviderAttrRanges[key] = rng
+	}
+	return ok
+}
+
+func missingBaseBlockDiagnostic(kind, name string, rng hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("Missing base %s for override", kind),
+		Detail: fmt.Sprintf("There is no %s %q to override. An override file can only override "+
+			"blocks declared in primary configuration files.", kind, name),
+		Subject: rng.Ptr(),
+	}
+}
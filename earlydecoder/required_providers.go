@@ -0,0 +1,281 @@
+package earlydecoder
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/terraform-schema/module"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type providerRequirement struct {
+	Source               string
+	VersionConstraints   []string
+	ConfigurationAliases []module.ProviderRef
+
+	// DeclRange is the range of the first required_providers entry
+	DeclRange hcl.Range
+
+	// DeclRanges contains ranges of all required_providers entries
+	// of the provider in order of declaration
+	DeclRanges []hcl.Range
+}
+
+// MapKey returns a string which identifies the requirement
+// of the given local name, including its normalized source
+func (pr *providerRequirement) MapKey(localName string) string {
+	req := &module.ProviderRequirement{
+		Source: pr.Source,
+	}
+	return req.MapKey(localName)
+}
+
+func decodeRequiredProvidersBlock(block *hcl.Block) (map[string]*providerRequirement, hcl.Diagnostics) {
+	attrs, diags := block.Body.JustAttributes()
+	reqs, reqsDiags := decodeRequiredProviders(attrs)
+	diags = append(diags, reqsDiags...)
+	return reqs, diags
+}
+
+// decodeRequiredProvidersAttribute decodes required_providers declared
+// as a single attribute, i.e. required_providers = { aws = { ... } },
+// in the same way as the equivalent block
+func decodeRequiredProvidersAttribute(attr *hcl.Attribute) (map[string]*providerRequirement, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	kvs, mapDiags := hcl.ExprMap(attr.Expr)
+	if mapDiags.HasErrors() {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid required_providers object",
+			Detail:   "Required providers must be declared as an object of provider names.",
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+		return make(map[string]*providerRequirement), diags
+	}
+
+	attrs := make(hcl.Attributes, len(kvs))
+	for _, kv := range kvs {
+		name := hcl.ExprAsKeyword(kv.Key)
+		if name == "" {
+			var key string
+			keyDiags := gohcl.DecodeExpression(kv.Key, nil, &key)
+			if keyDiags.HasErrors() {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid provider name",
+					Detail:   "Provider names in required_providers must be static strings.",
+					Subject:  kv.Key.Range().Ptr(),
+				})
+				continue
+			}
+			name = key
+		}
+
+		if _, exists := attrs[name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate required_providers entry",
+				Detail:   fmt.Sprintf("Found multiple entries for provider %q", name),
+				Subject:  kv.Key.Range().Ptr(),
+			})
+			continue
+		}
+
+		attrs[name] = &hcl.Attribute{
+			Name:      name,
+			Expr:      kv.Value,
+			Range:     hcl.RangeBetween(kv.Key.Range(), kv.Value.Range()),
+			NameRange: kv.Key.Range(),
+		}
+	}
+
+	reqs, reqsDiags := decodeRequiredProviders(attrs)
+	diags = append(diags, reqsDiags...)
+	return reqs, diags
+}
+
+// decodeRequiredProviders decodes the given entries of required_providers
+func decodeRequiredProviders(attrs hcl.Attributes) (map[string]*providerRequirement, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	reqs := make(map[string]*providerRequirement)
+	for name, attr := range attrs {
+		// Look for a legacy version in the attribute first
+		if expr, err := attr.Expr.Value(nil); err == nil && expr.Type().IsPrimitiveType() {
+			var version string
+			valDiags := gohcl.DecodeExpression(attr.Expr, nil, &version)
+			diags = append(diags, valDiags...)
+			if !valDiags.HasErrors() {
+				reqs[name] = &providerRequirement{
+					VersionConstraints: []string{version},
+					DeclRange:          attr.Range,
+					DeclRanges:         []hcl.Range{attr.Range},
+				}
+			}
+			continue
+		}
+
+		kvs, mapDiags := hcl.ExprMap(attr.Expr)
+		if mapDiags.HasErrors() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid required_providers object",
+				Detail:   "Required providers entries must be strings or objects.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			continue
+		}
+
+		// Entries may declare only the source, in which case
+		// the version constraints are empty rather than nil
+		pr := providerRequirement{
+			VersionConstraints: make([]string, 0),
+			DeclRange:          attr.Range,
+			DeclRanges:         []hcl.Range{attr.Range},
+		}
+
+		for _, kv := range kvs {
+			key, keyDiags := kv.Key.Value(nil)
+			if keyDiags.HasErrors() {
+				diags = append(diags, keyDiags...)
+				continue
+			}
+
+			if key.Type() != cty.String {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid Attribute",
+					Detail:   fmt.Sprintf("Invalid attribute value for provider requirement: %#v", key),
+					Subject:  kv.Key.Range().Ptr(),
+				})
+				continue
+			}
+
+			switch key.AsString() {
+			case "version":
+				version, valDiags := kv.Value.Value(nil)
+				if valDiags.HasErrors() || !version.Type().Equals(cty.String) {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unsuitable value type",
+						Detail:   "Unsuitable value: string required",
+						Subject:  attr.Expr.Range().Ptr(),
+					})
+					continue
+				}
+				if !version.IsNull() {
+					pr.VersionConstraints = append(pr.VersionConstraints, version.AsString())
+				}
+
+			case "source":
+				source, valDiags := kv.Value.Value(nil)
+				if valDiags.HasErrors() || !source.Type().Equals(cty.String) {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unsuitable value type",
+						Detail:   "Unsuitable value: string required",
+						Subject:  attr.Expr.Range().Ptr(),
+					})
+					continue
+				}
+
+				if !source.IsNull() {
+					pr.Source = source.AsString()
+				}
+			case "configuration_aliases":
+				// Valid aliases are kept even if others are malformed
+				aliases, valDiags := decodeConfigurationAliases(name, kv.Value)
+				diags = append(diags, valDiags...)
+				pr.ConfigurationAliases = append(pr.ConfigurationAliases, aliases...)
+			}
+		}
+
+		// The requirement is recorded even if some of the arguments
+		// failed to decode, such that the others can still be used
+		reqs[name] = &pr
+	}
+
+	return reqs, diags
+}
+
+func decodeConfigurationAliases(localName string, value hcl.Expression) ([]module.ProviderRef, hcl.Diagnostics) {
+	aliases := make([]module.ProviderRef, 0)
+	var diags hcl.Diagnostics
+
+	exprs, listDiags := hcl.ExprList(value)
+	if listDiags.HasErrors() {
+		diags = append(diags, listDiags...)
+		return aliases, diags
+	}
+
+	for _, expr := range exprs {
+		traversal, travDiags := hcl.AbsTraversalForExpr(expr)
+		if travDiags.HasErrors() {
+			diags = append(diags, travDiags...)
+			continue
+		}
+
+		ref, cfgDiags := parseProviderRef(traversal)
+		if cfgDiags.HasErrors() || len(traversal) != 2 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid configuration_aliases value",
+				Detail:   `Configuration aliases can only contain references to local provider configuration names in the format of provider.alias`,
+				Subject:  value.Range().Ptr(),
+			})
+			continue
+		}
+
+		if ref.LocalName != localName {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid configuration_aliases value",
+				Detail:   fmt.Sprintf(`Configuration aliases must be prefixed with the provider name. Expected %q, but found %q.`, localName, ref.LocalName),
+				Subject:  value.Range().Ptr(),
+			})
+			continue
+		}
+
+		aliases = append(aliases, ref)
+	}
+
+	return aliases, diags
+}
+
+func parseProviderRef(traversal hcl.Traversal) (module.ProviderRef, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	ret := module.ProviderRef{
+		LocalName: traversal.RootName(),
+	}
+
+	if len(traversal) < 2 {
+		// Just a local name, then.
+		return ret, diags
+	}
+
+	aliasStep := traversal[1]
+	switch ts := aliasStep.(type) {
+	case hcl.TraverseAttr:
+		ret.Alias = ts.Name
+		return ret, diags
+	default:
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid provider configuration address",
+			Detail:   "The provider type name must either stand alone or be followed by an alias name separated with a dot.",
+			Subject:  aliasStep.SourceRange().Ptr(),
+		})
+	}
+
+	if len(traversal) > 2 {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid provider configuration address",
+			Detail:   "Extraneous extra operators after provider configuration address.",
+			Subject:  traversal[2:].SourceRange().Ptr(),
+		})
+	}
+
+	return ret, diags
+}
@@ -0,0 +1,600 @@
+package earlydecoder
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-registry-address"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+func TestLoadModuleFromFile_configurationAliases(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      configuration_aliases = [aws.east, aws.west]
+    }
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedReqs := map[string]*providerRequirement{
+		"aws": {
+			Source:             "hashicorp/aws",
+			VersionConstraints: []string{},
+			ConfigurationAliases: []module.ProviderRef{
+				{LocalName: "aws", Alias: "east"},
+				{LocalName: "aws", Alias: "west"},
+			},
+			DeclRange: hcl.Range{
+				Filename: "test.tf",
+				Start:    hcl.Pos{Line: 4, Column: 5, Byte: 40},
+				End:      hcl.Pos{Line: 7, Column: 6, Byte: 150},
+			},
+			DeclRanges: []hcl.Range{
+				{
+					Filename: "test.tf",
+					Start:    hcl.Pos{Line: 4, Column: 5, Byte: 40},
+					End:      hcl.Pos{Line: 7, Column: 6, Byte: 150},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedReqs, mod.ProviderRequirements); diff != "" {
+		t.Fatalf("provider requirements don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_configurationAliasesInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"missing alias":    `[aws]`,
+		"extraneous steps": `[aws.east.foo]`,
+		"index step":       `[aws[0]]`,
+		"mismatching name": `[google.east]`,
+	}
+
+	for name, aliases := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mod := newDecodedModule()
+			diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      configuration_aliases = `+aliases+`
+    }
+  }
+}
+`), mod)
+			if len(diags) != 1 {
+				t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+			}
+			if diags[0].Summary != "Invalid configuration_aliases value" {
+				t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+			}
+		})
+	}
+}
+
+func TestDecodeRequiredProvidersBlock_shorthand(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  required_providers {
+    aws = ">= 2.0"
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 3.0"
+    }
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedReqs := map[string]*providerRequirement{
+		"aws": {
+			VersionConstraints: []string{">= 2.0"},
+		},
+		"google": {
+			Source:             "hashicorp/google",
+			VersionConstraints: []string{"~> 3.0"},
+		},
+	}
+	opts := cmpopts.IgnoreFields(providerRequirement{}, "DeclRange", "DeclRanges")
+	if diff := cmp.Diff(expectedReqs, mod.ProviderRequirements, opts); diff != "" {
+		t.Fatalf("provider requirements don't match: %s", diff)
+	}
+}
+
+func TestLoadModule_shorthandAcrossFiles(t *testing.T) {
+	files := map[string]*hcl.File{
+		"a.tf": mustParseFile(t, "a.tf", `
+terraform {
+  required_providers {
+    aws = ">= 2.0"
+  }
+}
+`),
+		"b.tf": mustParseFile(t, "b.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "< 4.0"
+    }
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedReq := &module.ProviderRequirement{
+		Source:             "hashicorp/aws",
+		VersionConstraints: []string{">= 2.0", "< 4.0"},
+	}
+	if diff := cmp.Diff(expectedReq, meta.RequiredProviders["aws"], ignoreDeclRanges); diff != "" {
+		t.Fatalf("provider requirement doesn't match: %s", diff)
+	}
+
+	aws := tfaddr.NewDefaultProvider("aws")
+	if constraints := meta.ProviderRequirements[aws].String(); constraints != ">= 2.0,< 4.0" {
+		t.Fatalf("unexpected constraints: %q", constraints)
+	}
+}
+
+func TestProviderRequirement_MapKey(t *testing.T) {
+	configs := map[string]string{
+		"a.tf": `
+terraform {
+  required_providers {
+    http = {
+      source = "hashicorp/http"
+    }
+  }
+}
+`,
+		"b.tf": `
+terraform {
+  required_providers {
+    http = {
+      source = "example/http"
+    }
+  }
+}
+`,
+		"c.tf": `
+terraform {
+  required_providers {
+    http = {
+      source = "registry.terraform.io/hashicorp/http"
+    }
+  }
+}
+`,
+	}
+
+	keys := make(map[string]string, 0)
+	files := make(map[string]*hcl.File, 0)
+	for name, cfg := range configs {
+		files[name] = mustParseFile(t, name, cfg)
+
+		mod := newDecodedModule()
+		diags := loadModuleFromFile(files[name], mod)
+		if len(diags) > 0 {
+			t.Fatal(diags)
+		}
+		keys[name] = mod.ProviderRequirements["http"].MapKey("http")
+	}
+
+	expectedKeys := map[string]string{
+		"a.tf": "http=registry.terraform.io/hashicorp/http",
+		"b.tf": "http=registry.terraform.io/example/http",
+		"c.tf": "http=registry.terraform.io/hashicorp/http",
+	}
+	if diff := cmp.Diff(expectedKeys, keys); diff != "" {
+		t.Fatalf("unexpected keys: %s", diff)
+	}
+
+	// equivalent sources don't conflict, unlike different ones
+	_, diags := LoadModule(t.TempDir(), files)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Multiple provider source attributes" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+	if diags[0].Subject.Filename != "b.tf" {
+		t.Fatalf("expected conflict to be reported in b.tf, given: %s", diags[0].Subject)
+	}
+}
+
+func TestLoadModuleFromFile_equivalentSourcesInFile(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+
+terraform {
+  required_providers {
+    aws = {
+      source = "registry.terraform.io/hashicorp/aws"
+    }
+    google = {
+      source = "hashicorp/google"
+    }
+  }
+}
+
+terraform {
+  required_providers {
+    google = {
+      source = "example/google"
+    }
+  }
+}
+`), mod)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Multiple provider source attributes" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+	if !strings.Contains(diags[0].Detail, "google") {
+		t.Fatalf("expected conflict of google, given: %s", diags[0].Detail)
+	}
+}
+
+func TestDecodeRequiredProvidersBlock_sourceOnly(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+`), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	req := mod.ProviderRequirements["aws"]
+	if req.Source != "hashicorp/aws" {
+		t.Fatalf("unexpected source: %q", req.Source)
+	}
+	if req.VersionConstraints == nil || len(req.VersionConstraints) != 0 {
+		t.Fatalf("expected empty version constraints, given: %#v", req.VersionConstraints)
+	}
+}
+
+func TestLoadModuleFromDir_splitRequirements(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "split-requirements"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedReq := &module.ProviderRequirement{
+		Source:             "hashicorp/aws",
+		VersionConstraints: []string{"~> 5.0"},
+	}
+	if diff := cmp.Diff(expectedReq, meta.RequiredProviders["aws"], ignoreDeclRanges); diff != "" {
+		t.Fatalf("provider requirement doesn't match: %s", diff)
+	}
+
+	aws := tfaddr.NewDefaultProvider("aws")
+	if constraints := meta.ProviderRequirements[aws].String(); constraints != "~> 5.0" {
+		t.Fatalf("unexpected constraints: %q", constraints)
+	}
+}
+
+func TestLoadModuleFromDir_duplicateConstraints(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "duplicate-constraints"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedConstraints := map[string]string{
+		"aws":    ">= 3.0",
+		"google": ">= 3.0, < 5.0",
+	}
+	for name, expected := range expectedConstraints {
+		pAddr := tfaddr.NewDefaultProvider(name)
+		if constraints := meta.ProviderRequirements[pAddr].String(); constraints != expected {
+			t.Fatalf("%s: expected constraints %q, given %q", name, expected, constraints)
+		}
+
+		effective, diags := meta.RequiredProviders[name].EffectiveConstraints()
+		if len(diags) > 0 {
+			t.Fatal(diags)
+		}
+		if effective.String() != expected {
+			t.Fatalf("%s: expected effective constraints %q, given %q", name, expected, effective.String())
+		}
+	}
+}
+
+func TestLoadModuleFromDir_jsonRequiredProviders(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "json-required-providers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedReqs := map[string]*module.ProviderRequirement{
+		"aws": {
+			Source:             "hashicorp/aws",
+			VersionConstraints: []string{">= 4.0"},
+			ConfigurationAliases: []module.ProviderRef{
+				{LocalName: "aws", Alias: "west"},
+			},
+		},
+		"random": {
+			Source:             "hashicorp/random",
+			VersionConstraints: []string{},
+		},
+		"null": {
+			VersionConstraints: []string{"~> 3.0"},
+		},
+	}
+	if diff := cmp.Diff(expectedReqs, meta.RequiredProviders, ignoreDeclRanges); diff != "" {
+		t.Fatalf("provider requirements don't match: %s", diff)
+	}
+
+	aws := tfaddr.NewDefaultProvider("aws")
+	if constraints := meta.ProviderRequirements[aws].String(); constraints != ">= 4.0" {
+		t.Fatalf("unexpected constraints: %q", constraints)
+	}
+	westRef := module.ProviderRef{LocalName: "aws", Alias: "west"}
+	if pAddr := meta.ProviderReferences[westRef]; pAddr != aws {
+		t.Fatalf("unexpected address of %s: %s", westRef, pAddr)
+	}
+}
+
+func TestLoadModule_requiredProvidersDeclRanges(t *testing.T) {
+	files := map[string]*hcl.File{
+		"a.tf": mustParseFile(t, "a.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = ">= 4.0"
+    }
+  }
+}
+`),
+		"b.tf": mustParseFile(t, "b.tf", `
+terraform {
+  required_providers {
+    aws = "< 6.0"
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	req := meta.RequiredProviders["aws"]
+	expectedRanges := []hcl.Range{
+		{
+			Filename: "a.tf",
+			Start:    hcl.Pos{Line: 4, Column: 5, Byte: 40},
+			End:      hcl.Pos{Line: 7, Column: 6, Byte: 110},
+		},
+		{
+			Filename: "b.tf",
+			Start:    hcl.Pos{Line: 4, Column: 5, Byte: 40},
+			End:      hcl.Pos{Line: 4, Column: 18, Byte: 53},
+		},
+	}
+	if diff := cmp.Diff(expectedRanges, req.DeclRanges); diff != "" {
+		t.Fatalf("unexpected ranges: %s", diff)
+	}
+	if diff := cmp.Diff(expectedRanges[0], req.DeclRange); diff != "" {
+		t.Fatalf("expected the first-seen range: %s", diff)
+	}
+
+	// the source argument is on line 5 of a.tf
+	if req.DeclRange.Start.Line > 5 || req.DeclRange.End.Line < 5 {
+		t.Fatalf("expected range to cover the source line, given: %s", req.DeclRange)
+	}
+}
+
+func TestLoadModuleFromFile_requiredProvidersAllFields(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("testdata", "required-providers-all-fields", "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "main.tf", string(src)), mod)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	req := mod.ProviderRequirements["aws"]
+	if req.Source != "hashicorp/aws" {
+		t.Fatalf("unexpected source: %q", req.Source)
+	}
+	if diff := cmp.Diff([]string{">= 5.0"}, req.VersionConstraints); diff != "" {
+		t.Fatalf("unexpected version constraints: %s", diff)
+	}
+	expectedAliases := []module.ProviderRef{
+		{LocalName: "aws", Alias: "east"},
+		{LocalName: "aws", Alias: "west"},
+	}
+	if diff := cmp.Diff(expectedAliases, req.ConfigurationAliases); diff != "" {
+		t.Fatalf("unexpected configuration aliases: %s", diff)
+	}
+}
+
+func TestLoadModuleFromFile_malformedConfigurationAliases(t *testing.T) {
+	mod := newDecodedModule()
+	diags := loadModuleFromFile(mustParseFile(t, "test.tf", `
+terraform {
+  required_providers {
+    aws = {
+      configuration_aliases = [aws.east, google.west]
+      source                = "hashicorp/aws"
+      version               = ">= 5.0"
+    }
+    google = {
+      configuration_aliases = "google.west"
+    }
+  }
+}
+`), mod)
+	if len(diags) != 2 {
+		t.Fatalf("expected exactly 2 diagnostics, %d given: %s", len(diags), diags)
+	}
+
+	expectedReqs := map[string]*providerRequirement{
+		"aws": {
+			Source:             "hashicorp/aws",
+			VersionConstraints: []string{">= 5.0"},
+			ConfigurationAliases: []module.ProviderRef{
+				{LocalName: "aws", Alias: "east"},
+			},
+		},
+		"google": {
+			VersionConstraints: []string{},
+		},
+	}
+	opts := cmpopts.IgnoreFields(providerRequirement{}, "DeclRange", "DeclRanges")
+	if diff := cmp.Diff(expectedReqs, mod.ProviderRequirements, opts); diff != "" {
+		t.Fatalf("provider requirements don't match: %s", diff)
+	}
+}
+
+func TestLoadModuleFromDir_requiredProvidersAttribute(t *testing.T) {
+	meta, diags, err := LoadModuleFromDir(filepath.Join("testdata", "required-providers-attribute"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	blockMeta, diags := LoadModule(t.TempDir(), map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    random = {
+      source = "hashicorp/random"
+    }
+  }
+}
+
+resource "aws_instance" "web" {}
+`),
+	})
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if diff := cmp.Diff(blockMeta.RequiredProviders, meta.RequiredProviders, ignoreDeclRanges); diff != "" {
+		t.Fatalf("provider requirements don't match: %s", diff)
+	}
+	if diff := cmp.Diff(blockMeta.ProviderRequirements, meta.ProviderRequirements, cmp.Comparer(compareVersionConstraint)); diff != "" {
+		t.Fatalf("effective provider requirements don't match: %s", diff)
+	}
+	if diff := cmp.Diff(blockMeta.ProviderReferences, meta.ProviderReferences); diff != "" {
+		t.Fatalf("provider references don't match: %s", diff)
+	}
+
+	expectedRange := hcl.Range{
+		Filename: filepath.Join("testdata", "required-providers-attribute", "main.tf"),
+		Start:    hcl.Pos{Line: 3, Column: 5, Byte: 41},
+		End:      hcl.Pos{Line: 6, Column: 6, Byte: 111},
+	}
+	if diff := cmp.Diff(expectedRange, meta.RequiredProviders["aws"].DeclRange); diff != "" {
+		t.Fatalf("unexpected range: %s", diff)
+	}
+}
+
+func TestLoadModule_requiredProvidersInvalidAttribute(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+terraform {
+  required_providers = "aws"
+}
+`),
+	}
+
+	_, diags := LoadModule(t.TempDir(), files)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Invalid required_providers object" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+}
+
+func TestLoadModule_requiredProvidersDeclRangeAfterProviderBlock(t *testing.T) {
+	files := map[string]*hcl.File{
+		"main.tf": mustParseFile(t, "main.tf", `
+provider "aws" {}
+
+terraform {
+  required_providers {
+    aws = { source = "hashicorp/aws" }
+  }
+}
+`),
+	}
+
+	meta, diags := LoadModule(t.TempDir(), files)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedRange := hcl.Range{
+		Filename: "main.tf",
+		Start:    hcl.Pos{Line: 6, Column: 5, Byte: 59},
+		End:      hcl.Pos{Line: 6, Column: 39, Byte: 93},
+	}
+	req := meta.RequiredProviders["aws"]
+	if diff := cmp.Diff([]hcl.Range{expectedRange}, req.DeclRanges); diff != "" {
+		t.Fatalf("unexpected ranges: %s", diff)
+	}
+	if diff := cmp.Diff(expectedRange, req.DeclRange); diff != "" {
+		t.Fatalf("unexpected range: %s", diff)
+	}
+}
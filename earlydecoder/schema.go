@@ -0,0 +1,547 @@
+package earlydecoder
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+var rootSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "terraform",
+			LabelNames: nil,
+		},
+		{
+			Type:       "variable",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "locals",
+			LabelNames: nil,
+		},
+		{
+			Type:       "output",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "provider",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "resource",
+			LabelNames: []string{"type", "name"},
+		},
+		{
+			Type:       "data",
+			LabelNames: []string{"type", "name"},
+		},
+		{
+			Type:       "ephemeral",
+			LabelNames: []string{"type", "name"},
+		},
+		{
+			Type:       "module",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "moved",
+			LabelNames: nil,
+		},
+		{
+			Type:       "import",
+			LabelNames: nil,
+		},
+		{
+			Type:       "removed",
+			LabelNames: nil,
+		},
+		{
+			Type:       "check",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "provider_meta",
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
+var terraformBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "required_version",
+		},
+		{
+			Name: "experiments",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "required_providers",
+		},
+		{
+			Type:       "backend",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type: "cloud",
+		},
+		{
+			Type:       "provider_meta",
+			LabelNames: []string{"provider"},
+		},
+	},
+}
+
+var cloudSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "organization",
+		},
+		{
+			Name: "hostname",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "workspaces",
+		},
+	},
+}
+
+var cloudWorkspacesSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "name",
+		},
+		{
+			Name: "tags",
+		},
+		{
+			Name: "project",
+		},
+	},
+}
+
+var providerConfigSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "version",
+		},
+		{
+			Name: "alias",
+		},
+	},
+}
+
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "type",
+		},
+		{
+			Name: "description",
+		},
+		{
+			Name: "default",
+		},
+		{
+			Name: "sensitive",
+		},
+		{
+			Name: "nullable",
+		},
+		{
+			Name: "ephemeral",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "validation",
+		},
+	},
+}
+
+var outputSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "description",
+		},
+		{
+			Name: "sensitive",
+		},
+		{
+			Name: "ephemeral",
+		},
+		{
+			Name: "value",
+		},
+		{
+			Name: "depends_on",
+		},
+	},
+}
+
+var resourceSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "provider",
+		},
+		{
+			Name: "count",
+		},
+		{
+			Name: "for_each",
+		},
+		{
+			Name: "depends_on",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "dynamic",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type: "lifecycle",
+		},
+		{
+			Type:       "provisioner",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type: "connection",
+		},
+	},
+}
+
+var ephemeralResourceSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "provider",
+		},
+		{
+			Name: "count",
+		},
+		{
+			Name: "for_each",
+		},
+		{
+			Name: "depends_on",
+		},
+	},
+}
+
+var provisionerSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "when",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "connection",
+		},
+	},
+}
+
+var resourceLifecycleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "create_before_destroy",
+		},
+		{
+			Name: "prevent_destroy",
+		},
+		{
+			Name: "ignore_changes",
+		},
+		{
+			Name: "replace_triggered_by",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "precondition",
+		},
+		{
+			Type: "postcondition",
+		},
+	},
+}
+
+var dataLifecycleSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "precondition",
+		},
+		{
+			Type: "postcondition",
+		},
+	},
+}
+
+var checkRuleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "condition",
+			Required: true,
+		},
+		{
+			Name:     "error_message",
+			Required: true,
+		},
+	},
+}
+
+var dynamicBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "for_each",
+			Required: true,
+		},
+		{
+			Name: "iterator",
+		},
+		{
+			Name: "labels",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "content",
+		},
+	},
+}
+
+var moduleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "source",
+		},
+		{
+			Name: "version",
+		},
+		{
+			Name: "depends_on",
+		},
+		{
+			Name: "providers",
+		},
+	},
+}
+
+var movedSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "from",
+			Required: true,
+		},
+		{
+			Name:     "to",
+			Required: true,
+		},
+	},
+}
+
+var importSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "to",
+			Required: true,
+		},
+		{
+			Name: "id",
+		},
+		{
+			Name: "identity",
+		},
+		{
+			Name: "provider",
+		},
+		{
+			Name: "for_each",
+		},
+	},
+}
+
+var removedSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "from",
+			Required: true,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "lifecycle",
+		},
+	},
+}
+
+var removedLifecycleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "destroy",
+		},
+	},
+}
+
+var checkSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "data",
+			LabelNames: []string{"type", "name"},
+		},
+		{
+			Type: "assert",
+		},
+	},
+}
+
+var testFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "run",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type: "variables",
+		},
+		{
+			Type:       "provider",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "mock_provider",
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
+var mockProviderSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "alias",
+		},
+		{
+			Name: "source",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "mock_resource",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type:       "mock_data",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type: "override_resource",
+		},
+		{
+			Type: "override_data",
+		},
+	},
+}
+
+var mockOverrideSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "target",
+			Required: true,
+		},
+		{
+			Name: "values",
+		},
+	},
+}
+
+var testRunSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "command",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "variables",
+		},
+		{
+			Type: "module",
+		},
+		{
+			Type: "assert",
+		},
+	},
+}
+
+var testRunModuleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "source",
+			Required: true,
+		},
+		{
+			Name: "version",
+		},
+	},
+}
+
+var stackFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "component",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type: "required_providers",
+		},
+		{
+			Type:       "variable",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "output",
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
+var stackComponentSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "source",
+		},
+		{
+			Name: "version",
+		},
+		{
+			Name: "inputs",
+		},
+		{
+			Name: "providers",
+		},
+	},
+}
+
+var deploymentFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "deployment",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "identity_token",
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
+var stackDeploymentSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "inputs",
+		},
+	},
+}
@@ -0,0 +1,207 @@
+package earlydecoder
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+// LoadStackFile decodes the given Terraform Stacks
+// configuration file (*.tfstack.hcl)
+func LoadStackFile(file *hcl.File) (*module.Stack, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	stack := &module.Stack{
+		Components:        make(map[string]*module.StackComponent, 0),
+		RequiredProviders: make(map[string]*module.ProviderRequirement, 0),
+		Variables:         make([]string, 0),
+		Outputs:           make([]string, 0),
+	}
+
+	content, _, contentDiags := file.Body.PartialContent(stackFileSchema)
+	diags = append(diags, contentDiags...)
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "component":
+			c, cDiags := decodeStackComponentBlock(block)
+			diags = append(diags, cDiags...)
+
+			if _, exists := stack.Components[c.Name]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple component definitions",
+					Detail:   fmt.Sprintf("Found multiple definitions of component %q", c.Name),
+					Subject:  &block.DefRange,
+				})
+				continue
+			}
+			stack.Components[c.Name] = c
+
+		case "required_providers":
+			reqs, reqsDiags := decodeRequiredProvidersBlock(block)
+			diags = append(diags, reqsDiags...)
+			for name, req := range reqs {
+				stack.RequiredProviders[name] = &module.ProviderRequirement{
+					Source:             req.Source,
+					VersionConstraints: req.VersionConstraints,
+				}
+			}
+
+		case "variable":
+			stack.Variables = append(stack.Variables, block.Labels[0])
+
+		case "output":
+			stack.Outputs = append(stack.Outputs, block.Labels[0])
+		}
+	}
+
+	sort.Strings(stack.Variables)
+	sort.Strings(stack.Outputs)
+
+	return stack, diags
+}
+
+func decodeStackComponentBlock(block *hcl.Block) (*module.StackComponent, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(stackComponentSchema)
+
+	c := &module.StackComponent{
+		Name:                block.Labels[0],
+		Inputs:              make([]string, 0),
+		Providers:           make([]string, 0),
+		ComponentReferences: make([]string, 0),
+		DeclRange:           block.DefRange,
+	}
+
+	if attr, defined := content.Attributes["source"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &c.Source)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, defined := content.Attributes["version"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &c.Version)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, defined := content.Attributes["inputs"]; defined {
+		inputs, inputDiags := decodeObjectKeys(attr)
+		diags = append(diags, inputDiags...)
+		c.Inputs = inputs
+		c.ComponentReferences = componentReferences(attr.Expr)
+	}
+
+	if attr, defined := content.Attributes["providers"]; defined {
+		providers, pDiags := decodeObjectKeys(attr)
+		diags = append(diags, pDiags...)
+		c.Providers = providers
+	}
+
+	return c, diags
+}
+
+// componentReferences returns sorted unique names of components
+// referenced by the given expression
+func componentReferences(expr hcl.Expression) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool, 0)
+
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "component" || len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok || seen[attr.Name] {
+			continue
+		}
+		seen[attr.Name] = true
+		names = append(names, attr.Name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// LoadDeploymentFile decodes the given Terraform Stacks
+// deployment file (*.tfdeploy.hcl)
+func LoadDeploymentFile(file *hcl.File) (*module.Deployment, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	deployment := &module.Deployment{
+		Deployments:    make(map[string]*module.StackDeployment, 0),
+		IdentityTokens: make([]string, 0),
+	}
+
+	content, _, contentDiags := file.Body.PartialContent(deploymentFileSchema)
+	diags = append(diags, contentDiags...)
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "deployment":
+			d, dDiags := decodeStackDeploymentBlock(block)
+			diags = append(diags, dDiags...)
+
+			if _, exists := deployment.Deployments[d.Name]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple deployment definitions",
+					Detail:   fmt.Sprintf("Found multiple definitions of deployment %q", d.Name),
+					Subject:  &block.DefRange,
+				})
+				continue
+			}
+			deployment.Deployments[d.Name] = d
+
+		case "identity_token":
+			deployment.IdentityTokens = append(deployment.IdentityTokens, block.Labels[0])
+		}
+	}
+
+	sort.Strings(deployment.IdentityTokens)
+
+	return deployment, diags
+}
+
+func decodeStackDeploymentBlock(block *hcl.Block) (*module.StackDeployment, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(stackDeploymentSchema)
+
+	d := &module.StackDeployment{
+		Name:      block.Labels[0],
+		Inputs:    make([]string, 0),
+		DeclRange: block.DefRange,
+	}
+
+	if attr, defined := content.Attributes["inputs"]; defined {
+		inputs, inputDiags := decodeObjectKeys(attr)
+		diags = append(diags, inputDiags...)
+		d.Inputs = inputs
+	}
+
+	return d, diags
+}
+
+// decodeObjectKeys returns sorted keys of the object
+// given as the value of the attribute, without evaluating
+// the values, which typically contain references
+func decodeObjectKeys(attr *hcl.Attribute) ([]string, hcl.Diagnostics) {
+	keys := make([]string, 0)
+
+	kvs, diags := hcl.ExprMap(attr.Expr)
+	if diags.HasErrors() {
+		return keys, diags
+	}
+
+	for _, kv := range kvs {
+		var key string
+		keyDiags := gohcl.DecodeExpression(kv.Key, nil, &key)
+		diags = append(diags, keyDiags...)
+		if !keyDiags.HasErrors() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, diags
+}
@@ -0,0 +1,89 @@
+package earlydecoder
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+func TestLoadStackFile(t *testing.T) {
+	f, diags := hclparse.NewParser().ParseHCLFile(filepath.Join("testdata", "stack", "components.tfstack.hcl"))
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	stack, diags := LoadStackFile(f)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedStack := &module.Stack{
+		Components: map[string]*module.StackComponent{
+			"app": {
+				Name:                "app",
+				Source:              "app.terraform.io/example/app/aws",
+				Version:             "1.0.0",
+				Inputs:              []string{"subnet_ids"},
+				Providers:           []string{"aws"},
+				ComponentReferences: []string{"network"},
+			},
+			"network": {
+				Name:                "network",
+				Source:              "./network",
+				Inputs:              []string{"cidr_block"},
+				Providers:           []string{"aws"},
+				ComponentReferences: []string{},
+			},
+		},
+		RequiredProviders: map[string]*module.ProviderRequirement{
+			"aws": {
+				Source:             "hashicorp/aws",
+				VersionConstraints: []string{"~> 5.0"},
+			},
+		},
+		Variables: []string{"identity_token", "regions"},
+		Outputs:   []string{"app_url"},
+	}
+	if diff := cmp.Diff(expectedStack, stack, ignoreDeclRanges); diff != "" {
+		t.Fatalf("unexpected stack: %s", diff)
+	}
+
+	if kind := stack.Components["app"].Kind(); kind != module.RegistryModuleSourceKind {
+		t.Fatalf("expected registry source, given: %s", kind)
+	}
+	if kind := stack.Components["network"].Kind(); kind != module.LocalModuleSourceKind {
+		t.Fatalf("expected local source, given: %s", kind)
+	}
+}
+
+func TestLoadDeploymentFile(t *testing.T) {
+	f, diags := hclparse.NewParser().ParseHCLFile(filepath.Join("testdata", "stack", "deployments.tfdeploy.hcl"))
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	deployment, diags := LoadDeploymentFile(f)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedDeployment := &module.Deployment{
+		Deployments: map[string]*module.StackDeployment{
+			"development": {
+				Name:   "development",
+				Inputs: []string{"identity_token", "regions"},
+			},
+			"production": {
+				Name:   "production",
+				Inputs: []string{"identity_token", "regions"},
+			},
+		},
+		IdentityTokens: []string{"aws"},
+	}
+	if diff := cmp.Diff(expectedDeployment, deployment, ignoreDeclRanges); diff != "" {
+		t.Fatalf("unexpected deployment: %s", diff)
+	}
+}
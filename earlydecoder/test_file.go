@@ -0,0 +1,183 @@
+package earlydecoder
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+// LoadTestFile decodes the given Terraform test file (*.tftest.hcl)
+func LoadTestFile(file *hcl.File) (*module.TestFile, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	tf := &module.TestFile{
+		Variables:     make(map[string]hcl.Expression, 0),
+		Providers:     make([]module.ProviderRef, 0),
+		MockProviders: make([]*module.MockProvider, 0),
+		Runs:          make([]*module.TestRun, 0),
+	}
+
+	content, _, contentDiags := file.Body.PartialContent(testFileSchema)
+	diags = append(diags, contentDiags...)
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "variables":
+			diags = append(diags, decodeTestVariables(block, tf.Variables)...)
+
+		case "provider":
+			pContent, _, pDiags := block.Body.PartialContent(providerConfigSchema)
+			diags = append(diags, pDiags...)
+
+			ref := module.ProviderRef{
+				LocalName: block.Labels[0],
+			}
+			if attr, defined := pContent.Attributes["alias"]; defined {
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ref.Alias)
+				diags = append(diags, valDiags...)
+			}
+			tf.Providers = append(tf.Providers, ref)
+
+		case "mock_provider":
+			mp, mpDiags := decodeMockProviderBlock(block)
+			diags = append(diags, mpDiags...)
+			tf.MockProviders = append(tf.MockProviders, mp)
+
+		case "run":
+			run, runDiags := decodeTestRunBlock(block)
+			diags = append(diags, runDiags...)
+			tf.Runs = append(tf.Runs, run)
+		}
+	}
+
+	return tf, diags
+}
+
+func decodeTestRunBlock(block *hcl.Block) (*module.TestRun, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(testRunSchema)
+
+	run := &module.TestRun{
+		Name:       block.Labels[0],
+		Command:    "apply",
+		Variables:  make(map[string]hcl.Expression, 0),
+		Assertions: make([]*module.CheckRule, 0),
+		DeclRange:  block.DefRange,
+	}
+
+	if attr, defined := content.Attributes["command"]; defined {
+		switch command := hcl.ExprAsKeyword(attr.Expr); command {
+		case "plan", "apply":
+			run.Command = command
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid run block command",
+				Detail:   "The command argument must be either plan or apply.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	for _, innerBlock := range content.Blocks {
+		switch innerBlock.Type {
+		case "variables":
+			diags = append(diags, decodeTestVariables(innerBlock, run.Variables)...)
+
+		case "module":
+			mContent, _, mDiags := innerBlock.Body.PartialContent(testRunModuleSchema)
+			diags = append(diags, mDiags...)
+
+			m := &module.TestRunModule{}
+			if attr, defined := mContent.Attributes["source"]; defined {
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &m.Source)
+				diags = append(diags, valDiags...)
+			}
+			if attr, defined := mContent.Attributes["version"]; defined {
+				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &m.Version)
+				diags = append(diags, valDiags...)
+			}
+			run.Module = m
+
+		case "assert":
+			rule, aDiags := decodeCheckRule(innerBlock)
+			diags = append(diags, aDiags...)
+			run.Assertions = append(run.Assertions, rule)
+		}
+	}
+
+	return run, diags
+}
+
+func decodeMockProviderBlock(block *hcl.Block) (*module.MockProvider, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(mockProviderSchema)
+
+	mp := &module.MockProvider{
+		LocalName:       block.Labels[0],
+		MockResources:   make([]string, 0),
+		MockDataSources: make([]string, 0),
+		OverrideTargets: make([]hcl.Traversal, 0),
+		DeclRange:       block.DefRange,
+	}
+
+	if attr, defined := content.Attributes["alias"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &mp.Alias)
+		diags = append(diags, valDiags...)
+	}
+
+	resources := make(map[string]bool, 0)
+	dataSources := make(map[string]bool, 0)
+
+	for _, innerBlock := range content.Blocks {
+		switch innerBlock.Type {
+		case "mock_resource":
+			resources[innerBlock.Labels[0]] = true
+		case "mock_data":
+			dataSources[innerBlock.Labels[0]] = true
+		case "override_resource", "override_data":
+			oContent, _, oDiags := innerBlock.Body.PartialContent(mockOverrideSchema)
+			diags = append(diags, oDiags...)
+
+			if attr, defined := oContent.Attributes["target"]; defined {
+				traversal, tDiags := decodeAddressAttribute(attr)
+				diags = append(diags, tDiags...)
+				if !tDiags.HasErrors() {
+					mp.OverrideTargets = append(mp.OverrideTargets, traversal)
+				}
+			}
+		}
+	}
+
+	for typeName := range resources {
+		mp.MockResources = append(mp.MockResources, typeName)
+	}
+	sort.Strings(mp.MockResources)
+
+	for typeName := range dataSources {
+		mp.MockDataSources = append(mp.MockDataSources, typeName)
+	}
+	sort.Strings(mp.MockDataSources)
+
+	return mp, diags
+}
+
+// decodeTestVariables decodes variable values
+// of the given variables block into vars
+func decodeTestVariables(block *hcl.Block, vars map[string]hcl.Expression) hcl.Diagnostics {
+	attrs, diags := block.Body.JustAttributes()
+	for name, attr := range attrs {
+		if _, exists := vars[name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate variable value",
+				Detail:   fmt.Sprintf("Found multiple values of variable %q", name),
+				Subject:  &attr.NameRange,
+			})
+			continue
+		}
+		vars[name] = attr.Expr
+	}
+	return diags
+}
@@ -0,0 +1,138 @@
+package earlydecoder
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+func TestLoadTestFile(t *testing.T) {
+	f, diags := hclparse.NewParser().ParseHCLFile(filepath.Join("testdata", "test-file", "main.tftest.hcl"))
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	tf, diags := LoadTestFile(f)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if _, ok := tf.Variables["region"]; !ok {
+		t.Fatalf("expected file-level variable, given: %#v", tf.Variables)
+	}
+
+	expectedProviders := []module.ProviderRef{
+		{LocalName: "aws"},
+		{LocalName: "aws", Alias: "west"},
+	}
+	if diff := cmp.Diff(expectedProviders, tf.Providers); diff != "" {
+		t.Fatalf("unexpected providers: %s", diff)
+	}
+
+	type run struct {
+		Name       string
+		Command    string
+		Module     *module.TestRunModule
+		Variables  []string
+		Assertions int
+	}
+	runs := make([]run, 0, len(tf.Runs))
+	for _, r := range tf.Runs {
+		vars := make([]string, 0)
+		for name := range r.Variables {
+			vars = append(vars, name)
+		}
+		runs = append(runs, run{
+			Name:       r.Name,
+			Command:    r.Command,
+			Module:     r.Module,
+			Variables:  vars,
+			Assertions: len(r.Assertions),
+		})
+	}
+
+	expectedRuns := []run{
+		{
+			Name:      "setup",
+			Command:   "apply",
+			Module:    &module.TestRunModule{Source: "./testing/setup"},
+			Variables: []string{},
+		},
+		{
+			Name:       "validate_plan",
+			Command:    "plan",
+			Variables:  []string{"bucket_name"},
+			Assertions: 1,
+		},
+		{
+			Name:    "apply_registry",
+			Command: "apply",
+			Module: &module.TestRunModule{
+				Source:  "terraform-aws-modules/s3-bucket/aws",
+				Version: "~> 4.0",
+			},
+			Variables:  []string{},
+			Assertions: 2,
+		},
+	}
+	if diff := cmp.Diff(expectedRuns, runs); diff != "" {
+		t.Fatalf("unexpected runs: %s", diff)
+	}
+}
+
+func TestLoadTestFile_invalidCommand(t *testing.T) {
+	f := mustParseFile(t, "main.tftest.hcl", `
+run "test" {
+  command = destroy
+}
+`)
+
+	tf, diags := LoadTestFile(f)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Invalid run block command" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0])
+	}
+	if tf.Runs[0].Command != "apply" {
+		t.Fatalf("expected default command, given: %q", tf.Runs[0].Command)
+	}
+}
+
+func TestLoadTestFile_mockProviders(t *testing.T) {
+	f, diags := hclparse.NewParser().ParseHCLFile(filepath.Join("testdata", "test-file", "main.tftest.hcl"))
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	tf, diags := LoadTestFile(f)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	if len(tf.MockProviders) != 1 {
+		t.Fatalf("expected exactly 1 mock provider, %d given", len(tf.MockProviders))
+	}
+	mp := tf.MockProviders[0]
+
+	if mp.LocalName != "aws" || mp.Alias != "mocked" {
+		t.Fatalf("unexpected mock provider: %s.%s", mp.LocalName, mp.Alias)
+	}
+	if diff := cmp.Diff([]string{"aws_instance", "aws_s3_bucket"}, mp.MockResources); diff != "" {
+		t.Fatalf("unexpected mocked resources: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"aws_caller_identity"}, mp.MockDataSources); diff != "" {
+		t.Fatalf("unexpected mocked data sources: %s", diff)
+	}
+
+	targets := make([]string, 0, len(mp.OverrideTargets))
+	for _, traversal := range mp.OverrideTargets {
+		targets = append(targets, traversalMapKey(traversal))
+	}
+	if diff := cmp.Diff([]string{"aws_s3_bucket.example"}, targets); diff != "" {
+		t.Fatalf("unexpected override targets: %s", diff)
+	}
+}
@@ -0,0 +1,7 @@
+﻿variable "name" {
+  type = string
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123456"
+}
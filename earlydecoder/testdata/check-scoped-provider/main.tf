@@ -0,0 +1,24 @@
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+check "bucket_exists" {
+  data "aws_s3_bucket" "logs" {
+    provider = aws.west
+    bucket   = "logs"
+  }
+
+  assert {
+    condition     = data.aws_s3_bucket.logs.id != ""
+    error_message = "The logs bucket doesn't exist"
+  }
+}
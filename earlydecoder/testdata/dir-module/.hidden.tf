@@ -0,0 +1 @@
+resource "hidden_resource" "test" {}
@@ -0,0 +1 @@
+resource "nested_resource" "test" {}
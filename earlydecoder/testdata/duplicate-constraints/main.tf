@@ -0,0 +1,20 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = ">= 3.0"
+    }
+    google = {
+      source  = "hashicorp/google"
+      version = ">= 3.0"
+    }
+  }
+}
+
+provider "aws" {
+  version = ">= 3.0"
+}
+
+provider "google" {
+  version = ">= 3.0, < 5.0"
+}
@@ -0,0 +1,16 @@
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+
+resource "aws_secretsmanager_secret_version" "db" {
+  secret_id = "db-password"
+}
+
+ephemeral "aws_secretsmanager_secret_version" "db" {
+  count     = 1
+  secret_id = aws_secretsmanager_secret_version.db.secret_id
+}
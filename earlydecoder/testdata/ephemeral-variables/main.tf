@@ -0,0 +1,17 @@
+variable "token" {
+  type      = string
+  ephemeral = true
+}
+
+variable "region" {
+  type = string
+}
+
+output "token" {
+  value     = var.token
+  ephemeral = true
+}
+
+output "endpoint" {
+  value = "https://${var.region}.example.com/?token=${var.token}"
+}
@@ -0,0 +1,7 @@
+module "network" {
+  source = "./modules/network"
+}
+
+module "network_copy" {
+  source = "./.terraform/modules/network"
+}
@@ -0,0 +1,4 @@
+import {
+  to = aws_instance.web
+  id = "i-abcd1234"
+}
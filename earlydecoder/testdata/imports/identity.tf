@@ -0,0 +1,8 @@
+import {
+  to = aws_instance.app
+  identity = {
+    account_id = "123456789012"
+    region     = "eu-west-1"
+    id         = "i-efgh5678"
+  }
+}
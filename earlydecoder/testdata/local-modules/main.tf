@@ -0,0 +1,15 @@
+module "valid" {
+  source = "./modules/valid"
+}
+
+module "empty" {
+  source = "./modules/empty"
+}
+
+module "missing" {
+  source = "./modules/missing"
+}
+
+module "registry" {
+  source = "hashicorp/consul/aws"
+}
@@ -0,0 +1,8 @@
+terraform {
+  required_version = ">= 1.5"
+
+  backend "s3" {
+    bucket = "state"
+    key    = "terraform.tfstate"
+  }
+}
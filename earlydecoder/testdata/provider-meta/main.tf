@@ -0,0 +1,19 @@
+terraform {
+  required_providers {
+    google = {
+      source = "hashicorp/google"
+    }
+  }
+
+  provider_meta "google" {
+    module_name = "blueprints/terraform/test/v0.0.1"
+  }
+}
+
+provider_meta "google" {
+  module_name = "misplaced"
+}
+
+provider "google" {
+  project = "test"
+}
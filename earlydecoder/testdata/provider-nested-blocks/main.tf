@@ -0,0 +1,18 @@
+provider "aws" {
+  region = "eu-west-1"
+
+  assume_role {
+    role_arn = "arn:aws:iam::123456789012:role/deploy"
+  }
+
+  default_tags {
+    tags = {
+      Environment = "test"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
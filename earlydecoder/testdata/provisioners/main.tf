@@ -0,0 +1,31 @@
+resource "aws_instance" "web" {
+  ami = "ami-123456"
+
+  connection {
+    type = "ssh"
+    user = "root"
+    host = self.public_ip
+  }
+
+  provisioner "local-exec" {
+    command = "echo ${self.private_ip} >> private_ips.txt"
+  }
+
+  provisioner "remote-exec" {
+    when = destroy
+
+    connection {
+      type = "ssh"
+      user = "admin"
+      host = self.public_ip
+    }
+
+    inline = [
+      "consul leave",
+    ]
+  }
+}
+
+resource "aws_instance" "db" {
+  ami = "ami-123456"
+}
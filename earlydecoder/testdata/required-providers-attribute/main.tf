@@ -0,0 +1,13 @@
+terraform {
+  required_providers = {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    "random" = {
+      source = "hashicorp/random"
+    }
+  }
+}
+
+resource "aws_instance" "web" {}
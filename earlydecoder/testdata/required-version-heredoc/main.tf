@@ -0,0 +1,5 @@
+terraform {
+  required_version = <<-EOT
+    >= 1.5, ${"< 2.0"}
+  EOT
+}
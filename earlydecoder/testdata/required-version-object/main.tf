@@ -0,0 +1,5 @@
+terraform {
+  required_version = {
+    min = "1.0"
+  }
+}
@@ -0,0 +1,7 @@
+terraform {
+  required_providers {
+    aws = {
+      version = "~> 5.0"
+    }
+  }
+}
@@ -0,0 +1,53 @@
+required_providers {
+  aws = {
+    source  = "hashicorp/aws"
+    version = "~> 5.0"
+  }
+}
+
+variable "regions" {
+  type = set(string)
+}
+
+variable "identity_token" {
+  type      = string
+  ephemeral = true
+}
+
+provider "aws" "configurations" {
+  for_each = var.regions
+
+  config {
+    region = each.value
+  }
+}
+
+component "network" {
+  source = "./network"
+
+  inputs = {
+    cidr_block = "10.0.0.0/16"
+  }
+
+  providers = {
+    aws = provider.aws.configurations["eu-west-1"]
+  }
+}
+
+component "app" {
+  source  = "app.terraform.io/example/app/aws"
+  version = "1.0.0"
+
+  inputs = {
+    subnet_ids = component.network.subnet_ids
+  }
+
+  providers = {
+    aws = provider.aws.configurations["eu-west-1"]
+  }
+}
+
+output "app_url" {
+  type  = string
+  value = component.app.url
+}
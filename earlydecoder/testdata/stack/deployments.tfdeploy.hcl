@@ -0,0 +1,17 @@
+identity_token "aws" {
+  audience = ["aws.workload.identity"]
+}
+
+deployment "development" {
+  inputs = {
+    regions        = ["eu-west-1"]
+    identity_token = identity_token.aws.jwt
+  }
+}
+
+deployment "production" {
+  inputs = {
+    regions        = ["eu-west-1", "us-east-1"]
+    identity_token = identity_token.aws.jwt
+  }
+}
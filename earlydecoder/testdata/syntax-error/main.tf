@@ -0,0 +1,9 @@
+resource "aws_instance" "web" {
+  ami = "ami-123456"
+}
+
+resource "aws_instance" "broken" {
+  ami =
+}
+
+variable "name" {}
@@ -0,0 +1,79 @@
+variables {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  region = var.region
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+run "setup" {
+  module {
+    source = "./testing/setup"
+  }
+}
+
+run "validate_plan" {
+  command = plan
+
+  variables {
+    bucket_name = "example"
+  }
+
+  assert {
+    condition     = aws_s3_bucket.example.bucket == "example"
+    error_message = "Unexpected bucket name"
+  }
+}
+
+run "apply_registry" {
+  command = apply
+
+  module {
+    source  = "terraform-aws-modules/s3-bucket/aws"
+    version = "~> 4.0"
+  }
+
+  assert {
+    condition     = output.bucket_id != ""
+    error_message = "Bucket ID must be set"
+  }
+
+  assert {
+    condition     = length(output.tags) > 0
+    error_message = "Tags must be set"
+  }
+}
+
+mock_provider "aws" {
+  alias = "mocked"
+
+  mock_resource "aws_s3_bucket" {
+    defaults = {
+      arn = "arn:aws:s3:::example"
+    }
+  }
+
+  mock_resource "aws_instance" {
+    defaults = {
+      id = "i-12345678"
+    }
+  }
+
+  mock_data "aws_caller_identity" {
+    defaults = {
+      account_id = "123456789012"
+    }
+  }
+
+  override_resource {
+    target = aws_s3_bucket.example
+    values = {
+      bucket = "overridden"
+    }
+  }
+}
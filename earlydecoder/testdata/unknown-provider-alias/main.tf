@@ -0,0 +1,15 @@
+variable "region" {
+  type = string
+}
+
+provider "aws" {
+}
+
+provider "aws" {
+  alias  = var.region
+  region = var.region
+}
+
+resource "aws_instance" "web" {
+  provider = aws.west
+}
@@ -0,0 +1,17 @@
+variable "image_id" {
+  type = string
+
+  validation {
+    condition     = length(var.image_id) > 4
+    error_message = "The image_id value must be longer than 4 characters."
+  }
+
+  validation {
+    condition     = can(regex("^ami-", var.image_id))
+    error_message = "The image_id value must start with \"ami-\"."
+  }
+}
+
+variable "name" {
+  type = string
+}
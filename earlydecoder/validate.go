@@ -0,0 +1,322 @@
+package earlydecoder
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-schema/module"
+)
+
+// validateRemovedBlocks checks that removed blocks do not point
+// to objects which are still declared in the module
+func validateRemovedBlocks(mod *decodedModule) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for _, r := range mod.Removed {
+		if len(r.From) == 0 {
+			continue
+		}
+		key := traversalMapKey(r.From)
+
+		_, resourceExists := mod.Resources[key]
+		_, moduleExists := mod.ModuleSources[key]
+		if resourceExists || moduleExists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Removed object still declared",
+				Detail:   fmt.Sprintf("%s is marked as removed, but it is still declared in the module", key),
+				Subject:  r.From.SourceRange().Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// validateProviderAliases checks that aliased provider references
+// of resources and data sources have a matching provider block,
+// or are declared via configuration_aliases
+func validateProviderAliases(mod *decodedModule) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	declared := make(map[module.ProviderRef]bool, 0)
+	// any alias may be declared by a configuration whose alias is unknown
+	unknownAliases := make(map[string]bool, 0)
+	for _, cfg := range mod.ProviderConfigs {
+		if cfg.AliasUnknown {
+			unknownAliases[cfg.Name] = true
+			continue
+		}
+		declared[module.ProviderRef{LocalName: cfg.Name, Alias: cfg.Alias}] = true
+	}
+	for _, req := range mod.ProviderRequirements {
+		for _, alias := range req.ConfigurationAliases {
+			declared[alias] = true
+		}
+	}
+
+	// Only aliased references need to be checked, which tends
+	// to be a small fraction of all resources and data sources
+	refs := make(map[string]module.ProviderRef, 0)
+	for key, r := range mod.Resources {
+		if r.Provider.Alias != "" && !declared[r.Provider] && !unknownAliases[r.Provider.LocalName] {
+			refs[key] = r.Provider
+		}
+	}
+	for key, ds := range mod.DataSources {
+		if ds.Provider.Alias != "" && !declared[ds.Provider] && !unknownAliases[ds.Provider.LocalName] {
+			refs[key] = ds.Provider
+		}
+	}
+	for _, c := range mod.Checks {
+		for key, ds := range c.ScopedDataSources {
+			if ds.Provider.Alias != "" && !declared[ds.Provider] && !unknownAliases[ds.Provider.LocalName] {
+				refs[checkScopedKey(c.Name, key)] = ds.Provider
+			}
+		}
+	}
+	for key, er := range mod.EphemeralResources {
+		if er.Provider.Alias != "" && !declared[er.Provider] && !unknownAliases[er.Provider.LocalName] {
+			refs[key] = er.Provider
+		}
+	}
+
+	keys := make([]string, 0, len(refs))
+	for key := range refs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ref := refs[key]
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Reference to undeclared provider configuration",
+			Detail: fmt.Sprintf("%s refers to provider configuration %s, which is not declared "+
+				"in any provider block or in configuration_aliases", key, ref),
+		}
+		if rng, ok := mod.ProviderAttrRanges[key]; ok {
+			diag.Subject = rng.Ptr()
+		}
+		diags = append(diags, diag)
+	}
+
+	return diags
+}
+
+// validateMoveEndpoints checks that both endpoints of the moved block
+// are valid and either both refer to resources or both to modules
+func validateMoveEndpoints(m *module.Moved, rng hcl.Range) hcl.Diagnostics {
+	from, diags := module.ParseMoveEndpoint(m.From)
+	to, toDiags := module.ParseMoveEndpoint(m.To)
+	diags = append(diags, toDiags...)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	if from.IsModule() != to.IsModule() {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  `Invalid "moved" addresses`,
+			Detail: fmt.Sprintf("The \"from\" and \"to\" addresses must either both refer to resources "+
+				"or both refer to modules, given %s and %s.", from.Kind, to.Kind),
+			Subject: rng.Ptr(),
+		})
+	}
+
+	return diags
+}
+
+// validateProviderMetas checks that provider_meta blocks
+// refer to providers which are required by the module
+func validateProviderMetas(mod *decodedModule) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for name, pm := range mod.ProviderMetas {
+		if _, required := mod.ProviderRequirements[name]; !required {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Undeclared provider in provider_meta",
+				Detail:   fmt.Sprintf("provider_meta refers to provider %q, which is not declared in required_providers", name),
+				Subject:  pm.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// traversalMapKey returns the map key of an object (e.g. resource)
+// the given traversal refers to, ignoring any instance keys
+func traversalMapKey(traversal hcl.Traversal) string {
+	key := traversal.RootName()
+	for _, step := range traversal[1:] {
+		if attr, ok := step.(hcl.TraverseAttr); ok {
+			key += "." + attr.Name
+		}
+	}
+	return key
+}
+
+// reservedModuleNames are names which cannot be used for module calls
+// as they would be ambiguous in references
+var reservedModuleNames = map[string]bool{
+	"terraform": true,
+}
+
+// validateModuleName checks that the name of a module call
+// is a valid identifier and is not reserved
+func validateModuleName(name string, rng hcl.Range) hcl.Diagnostics {
+	if !hclsyntax.ValidIdentifier(name) {
+		return hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid module instance name",
+				Detail:   "A name must start with a letter or underscore and may contain only letters, digits, underscores, and dashes.",
+				Subject:  rng.Ptr(),
+			},
+		}
+	}
+
+	if reservedModuleNames[name] {
+		return hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Reserved module name",
+				Detail:   fmt.Sprintf("The name %q is reserved and cannot be used for a module call.", name),
+				Subject:  rng.Ptr(),
+			},
+		}
+	}
+
+	return nil
+}
+
+var constraintOperatorRe = regexp.MustCompile(`^\s*(=|!=|>=|<=|>|<|~>)?\s*(\S+)\s*$`)
+
+type versionBound struct {
+	version   *version.Version
+	inclusive bool
+}
+
+// validateCoreRequirements checks that at least one version
+// of Terraform can satisfy all the given constraints, which
+// may have been declared across multiple files.
+//
+// Exclusions (!=) are ignored as they cannot make a range empty
+// unless it is already narrowed down to a single version.
+func validateCoreRequirements(constraints version.Constraints) hcl.Diagnostics {
+	var lower, upper *versionBound
+
+	for _, c := range constraints {
+		matches := constraintOperatorRe.FindStringSubmatch(c.String())
+		if matches == nil {
+			continue
+		}
+		v, err := version.NewVersion(matches[2])
+		if err != nil {
+			continue
+		}
+
+		switch matches[1] {
+		case "", "=":
+			lower = maxLowerBound(lower, &versionBound{v, true})
+			upper = minUpperBound(upper, &versionBound{v, true})
+		case ">":
+			lower = maxLowerBound(lower, &versionBound{v, false})
+		case ">=":
+			lower = maxLowerBound(lower, &versionBound{v, true})
+		case "<":
+			upper = minUpperBound(upper, &versionBound{v, false})
+		case "<=":
+			upper = minUpperBound(upper, &versionBound{v, true})
+		case "~>":
+			lower = maxLowerBound(lower, &versionBound{v, true})
+			if ub, ok := pessimisticUpperBound(matches[2], v); ok {
+				upper = minUpperBound(upper, &versionBound{ub, false})
+			}
+		}
+	}
+
+	if lower == nil || upper == nil {
+		return nil
+	}
+
+	cmp := lower.version.Compare(upper.version)
+	if cmp < 0 || (cmp == 0 && lower.inclusive && upper.inclusive) {
+		return nil
+	}
+
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Conflicting terraform requirements",
+			Detail: fmt.Sprintf("No version of Terraform can satisfy all of the required_version constraints (%s)",
+				constraints.String()),
+		},
+	}
+}
+
+func maxLowerBound(current, given *versionBound) *versionBound {
+	if current == nil {
+		return given
+	}
+	cmp := given.version.Compare(current.version)
+	if cmp > 0 || (cmp == 0 && !given.inclusive) {
+		return given
+	}
+	return current
+}
+
+func minUpperBound(current, given *versionBound) *versionBound {
+	if current == nil {
+		return given
+	}
+	cmp := given.version.Compare(current.version)
+	if cmp < 0 || (cmp == 0 && !given.inclusive) {
+		return given
+	}
+	return current
+}
+
+// pessimisticUpperBound returns the (exclusive) upper bound
+// of the ~> operator, e.g. 2.0.0 for ~> 1.2 or 1.3.0 for ~> 1.2.3
+//
+// ~> with a single segment (e.g. ~> 1) has no upper bound.
+func pessimisticUpperBound(raw string, v *version.Version) (*version.Version, bool) {
+	raw = strings.TrimPrefix(raw, "v")
+	if idx := strings.IndexAny(raw, "-+"); idx > -1 {
+		raw = raw[:idx]
+	}
+	specified := len(strings.Split(raw, "."))
+	if specified < 2 {
+		return nil, false
+	}
+
+	segments := v.Segments()
+	bumpIdx := specified - 2
+
+	parts := make([]string, len(segments))
+	for i, s := range segments {
+		switch {
+		case i < bumpIdx:
+			parts[i] = strconv.Itoa(s)
+		case i == bumpIdx:
+			parts[i] = strconv.Itoa(s + 1)
+		default:
+			parts[i] = "0"
+		}
+	}
+
+	ub, err := version.NewVersion(strings.Join(parts, "."))
+	if err != nil {
+		return nil, false
+	}
+	return ub, true
+}
@@ -0,0 +1,64 @@
+package earlydecoder
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// LoadVarsFromFile decodes variable values from the given
+// variable definitions file, such as terraform.tfvars
+// or *.auto.tfvars (or their JSON equivalents).
+//
+// Only constant expressions are evaluated. Values of expressions
+// which reference variables or call functions (or are otherwise
+// invalid without an evaluation context) are unknown.
+func LoadVarsFromFile(file *hcl.File) (map[string]cty.Value, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	vals := make(map[string]cty.Value, 0)
+
+	body := file.Body
+	if syntaxBody, ok := body.(*hclsyntax.Body); ok {
+		for _, block := range syntaxBody.Blocks {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unexpected block in variable definitions file",
+				Detail: fmt.Sprintf("Blocks (such as %q) are not allowed in variable definitions files, "+
+					"only variable values can be assigned, like name = \"value\".", block.Type),
+				Subject: block.DefRange().Ptr(),
+			})
+		}
+		// Blocks are reported above in more detail, so we can ignore
+		// the generic diagnostic which JustAttributes would return
+		body = &hclsyntax.Body{
+			Attributes: syntaxBody.Attributes,
+			SrcRange:   syntaxBody.SrcRange,
+			EndRange:   syntaxBody.EndRange,
+		}
+	}
+
+	attrs, attrDiags := body.JustAttributes()
+	diags = append(diags, attrDiags...)
+
+	for name, attr := range attrs {
+		vals[name] = constantValue(attr.Expr)
+	}
+
+	return vals, diags
+}
+
+// constantValue returns the value of the given expression
+// or unknown value if it cannot be evaluated without context
+func constantValue(expr hcl.Expression) cty.Value {
+	if len(expr.Variables()) > 0 {
+		return cty.DynamicVal
+	}
+
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.DynamicVal
+	}
+	return val
+}
@@ -0,0 +1,95 @@
+package earlydecoder
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty-debug/ctydebug"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestLoadVarsFromFile(t *testing.T) {
+	f := mustParseFile(t, "terraform.tfvars", `
+name    = "web"
+zones   = ["eu-west-1a", "eu-west-1b"]
+network = {
+  cidr    = "10.0.0.0/16"
+  private = true
+}
+upper   = upper("web")
+ref     = var.name
+`)
+
+	vals, diags := LoadVarsFromFile(f)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedVals := map[string]cty.Value{
+		"name":  cty.StringVal("web"),
+		"zones": cty.TupleVal([]cty.Value{cty.StringVal("eu-west-1a"), cty.StringVal("eu-west-1b")}),
+		"network": cty.ObjectVal(map[string]cty.Value{
+			"cidr":    cty.StringVal("10.0.0.0/16"),
+			"private": cty.True,
+		}),
+		"upper": cty.DynamicVal,
+		"ref":   cty.DynamicVal,
+	}
+	if diff := cmp.Diff(expectedVals, vals, ctydebug.CmpOptions); diff != "" {
+		t.Fatalf("unexpected values: %s", diff)
+	}
+}
+
+func TestLoadVarsFromFile_json(t *testing.T) {
+	f, diags := hcljson.Parse([]byte(`{
+  "name": "web",
+  "zones": ["eu-west-1a"],
+  "ref": "${var.name}"
+}`), "terraform.tfvars.json")
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	vals, diags := LoadVarsFromFile(f)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	expectedVals := map[string]cty.Value{
+		"name":  cty.StringVal("web"),
+		"zones": cty.TupleVal([]cty.Value{cty.StringVal("eu-west-1a")}),
+		"ref":   cty.DynamicVal,
+	}
+	if diff := cmp.Diff(expectedVals, vals, ctydebug.CmpOptions); diff != "" {
+		t.Fatalf("unexpected values: %s", diff)
+	}
+}
+
+func TestLoadVarsFromFile_blocks(t *testing.T) {
+	f := mustParseFile(t, "terraform.tfvars", `
+name = "web"
+
+variable "name" {
+  default = "db"
+}
+`)
+
+	vals, diags := LoadVarsFromFile(f)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+	if diags[0].Summary != "Unexpected block in variable definitions file" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if diags[0].Subject.Start.Line != 4 {
+		t.Fatalf("expected diagnostic to point to the block, given: %s", diags[0].Subject)
+	}
+
+	expectedVals := map[string]cty.Value{
+		"name": cty.StringVal("web"),
+	}
+	if diff := cmp.Diff(expectedVals, vals, ctydebug.CmpOptions); diff != "" {
+		t.Fatalf("unexpected values: %s", diff)
+	}
+}
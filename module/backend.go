@@ -0,0 +1,13 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+type Backend struct {
+	Type string
+
+	// Config represents the body of the backend block,
+	// which can be decoded lazily with a backend-specific schema
+	Config hcl.Body
+}
@@ -0,0 +1,18 @@
+package module
+
+// Check represents a check block with its scoped
+// data sources and assertions
+type Check struct {
+	Name string
+
+	// DataSources contains addresses of data sources scoped to the check,
+	// e.g. data.http.health
+	DataSources []string
+
+	// ScopedDataSources represents the data sources scoped to the check,
+	// keyed by the addresses in DataSources. Only the provider
+	// and the range of the block header are decoded.
+	ScopedDataSources map[string]*DataSource
+
+	AssertionCount int
+}
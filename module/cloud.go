@@ -0,0 +1,16 @@
+package module
+
+type CloudConfig struct {
+	Organization string
+	Hostname     string
+
+	Workspaces *CloudWorkspaces
+}
+
+// CloudWorkspaces represents the workspaces block
+// which selects workspaces either by Name or by Tags
+type CloudWorkspaces struct {
+	Name    string
+	Tags    []string
+	Project string
+}
@@ -0,0 +1,23 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// ErrorsOnly returns only diagnostics of error severity,
+// or nil if there are none
+func ErrorsOnly(diags hcl.Diagnostics) hcl.Diagnostics {
+	var errs hcl.Diagnostics
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagError {
+			errs = append(errs, diag)
+		}
+	}
+	return errs
+}
+
+// HasErrors returns true if any of the diagnostics
+// produced when decoding the module is an error
+func (m *Meta) HasErrors() bool {
+	return len(ErrorsOnly(m.Diagnostics)) > 0
+}
@@ -0,0 +1,48 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestErrorsOnly(t *testing.T) {
+	warning := &hcl.Diagnostic{Severity: hcl.DiagWarning, Summary: "warning"}
+	firstErr := &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "first error"}
+	secondErr := &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "second error"}
+
+	diags := hcl.Diagnostics{warning, firstErr, warning, secondErr}
+	expectedDiags := hcl.Diagnostics{firstErr, secondErr}
+	if diff := cmp.Diff(expectedDiags, ErrorsOnly(diags)); diff != "" {
+		t.Fatalf("unexpected errors: %s", diff)
+	}
+
+	if errs := ErrorsOnly(hcl.Diagnostics{warning}); errs != nil {
+		t.Fatalf("expected no errors, given: %s", errs)
+	}
+}
+
+func TestMeta_HasErrors(t *testing.T) {
+	warning := &hcl.Diagnostic{Severity: hcl.DiagWarning, Summary: "warning"}
+	err := &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "error"}
+
+	testCases := []struct {
+		name     string
+		diags    hcl.Diagnostics
+		expected bool
+	}{
+		{"no diagnostics", nil, false},
+		{"warnings only", hcl.Diagnostics{warning, warning}, false},
+		{"mixed", hcl.Diagnostics{warning, err}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := &Meta{Diagnostics: tc.diags}
+			if meta.HasErrors() != tc.expected {
+				t.Fatalf("expected %t, given %t", tc.expected, meta.HasErrors())
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package module
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Dump writes a human-readable summary of the given metadata to w,
+// i.e. providers, resources, data sources, module calls, variables
+// and outputs, each sorted for deterministic output.
+//
+// The format is meant for debugging only and may change at any time,
+// use MarshalJSON for a stable serialization.
+func Dump(w io.Writer, meta *Meta) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Path: %s\n", meta.Path)
+	if len(meta.CoreRequirements) > 0 {
+		fmt.Fprintf(&buf, "Terraform: %s\n", meta.CoreRequirements)
+	}
+
+	providers := meta.Providers()
+	fmt.Fprintf(&buf, "Providers (%d):\n", len(providers))
+	for _, p := range providers {
+		fmt.Fprintf(&buf, "  %s = %s", p.LocalName, p.Addr)
+		if constraints := meta.ProviderRequirements[p.Addr]; len(constraints) > 0 {
+			fmt.Fprintf(&buf, " %s", constraints)
+		}
+		if !p.Required {
+			buf.WriteString(" (inferred)")
+		}
+		buf.WriteString("\n")
+	}
+
+	fmt.Fprintf(&buf, "Provider configurations (%d):\n", len(meta.ProviderConfigs))
+	for _, pc := range meta.ProviderConfigs {
+		if pc.AliasUnknown {
+			fmt.Fprintf(&buf, "  %s.<unknown>\n", pc.LocalName)
+			continue
+		}
+		fmt.Fprintf(&buf, "  %s\n", pc.Ref())
+	}
+
+	resources := meta.SortedResources()
+	fmt.Fprintf(&buf, "Resources (%d):\n", len(resources))
+	for _, r := range resources {
+		fmt.Fprintf(&buf, "  %s provider=%s%s\n", r.MapKey(), r.Provider, repetitionFlags(r.Count, r.ForEach))
+	}
+
+	dataSources := meta.SortedDataSources()
+	fmt.Fprintf(&buf, "Data sources (%d):\n", len(dataSources))
+	for _, ds := range dataSources {
+		fmt.Fprintf(&buf, "  %s provider=%s%s\n", ds.MapKey(), ds.Provider, repetitionFlags(ds.Count, ds.ForEach))
+	}
+
+	ephemeralResources := meta.SortedEphemeralResources()
+	fmt.Fprintf(&buf, "Ephemeral resources (%d):\n", len(ephemeralResources))
+	for _, er := range ephemeralResources {
+		fmt.Fprintf(&buf, "  %s provider=%s%s\n", er.MapKey(), er.Provider, repetitionFlags(er.Count, er.ForEach))
+	}
+
+	moduleSources := meta.SortedModuleSources()
+	fmt.Fprintf(&buf, "Module calls (%d):\n", len(moduleSources))
+	for _, ms := range moduleSources {
+		fmt.Fprintf(&buf, "  %s source=%q", ms.Name, ms.Source)
+		if ms.Version != "" {
+			fmt.Fprintf(&buf, " version=%q", ms.Version)
+		}
+		buf.WriteString("\n")
+	}
+
+	variables := meta.SortedVariables()
+	fmt.Fprintf(&buf, "Variables (%d):\n", len(variables))
+	for _, v := range variables {
+		var flags []string
+		if v.DefaultValue != cty.NilVal {
+			flags = append(flags, "default")
+		}
+		if v.IsSensitive {
+			flags = append(flags, "sensitive")
+		}
+		if v.Ephemeral {
+			flags = append(flags, "ephemeral")
+		}
+		if !v.IsNullable {
+			flags = append(flags, "non-nullable")
+		}
+		fmt.Fprintf(&buf, "  %s type=%s%s\n", v.Name, v.Type.FriendlyName(), formatFlags(flags))
+	}
+
+	outputs := meta.SortedOutputs()
+	fmt.Fprintf(&buf, "Outputs (%d):\n", len(outputs))
+	for _, o := range outputs {
+		var flags []string
+		if o.IsSensitive {
+			flags = append(flags, "sensitive")
+		}
+		if o.Ephemeral {
+			flags = append(flags, "ephemeral")
+		}
+		fmt.Fprintf(&buf, "  %s%s\n", o.Name, formatFlags(flags))
+	}
+
+	if len(meta.Diagnostics) > 0 {
+		fmt.Fprintf(&buf, "Diagnostics (%d):\n", len(meta.Diagnostics))
+		for _, diag := range meta.Diagnostics {
+			fmt.Fprintf(&buf, "  %s\n", diag.Error())
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// repetitionFlags returns the flags of a block
+// declaring count or for_each, if any
+func repetitionFlags(count, forEach hcl.Expression) string {
+	var flags []string
+	if count != nil {
+		flags = append(flags, "count")
+	}
+	if forEach != nil {
+		flags = append(flags, "for_each")
+	}
+	return formatFlags(flags)
+}
+
+func formatFlags(flags []string) string {
+	if len(flags) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(flags, ", ") + ")"
+}
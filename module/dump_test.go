@@ -0,0 +1,136 @@
+package module
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	tfaddr "github.com/hashicorp/terraform-registry-address"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func TestDump(t *testing.T) {
+	awsAddr := tfaddr.NewDefaultProvider("aws")
+	meta := &Meta{
+		Path:             "path",
+		CoreRequirements: mustConstraints(t, ">= 1.5"),
+		ProviderReferences: map[ProviderRef]tfaddr.Provider{
+			{LocalName: "aws"}: awsAddr,
+		},
+		ProviderRequirements: map[tfaddr.Provider]version.Constraints{
+			awsAddr: mustConstraints(t, "~> 5.0"),
+		},
+		RequiredProviders: map[string]*ProviderRequirement{
+			"aws": {
+				Source:             "hashicorp/aws",
+				VersionConstraints: []string{"~> 5.0"},
+				DeclRanges:         []hcl.Range{{Filename: "main.tf"}},
+			},
+		},
+		ProviderConfigs: []*ProviderConfig{
+			{LocalName: "aws"},
+			{LocalName: "aws", Alias: "west"},
+		},
+		Resources: map[string]*Resource{
+			"aws_instance.db": {
+				Type:     "aws_instance",
+				Name:     "db",
+				Provider: ProviderRef{LocalName: "aws", Alias: "west"},
+			},
+			"aws_instance.web": {
+				Type:     "aws_instance",
+				Name:     "web",
+				Provider: ProviderRef{LocalName: "aws"},
+				Count:    hcl.StaticExpr(cty.NumberIntVal(2), hcl.Range{}),
+			},
+			"random_id.suffix": {
+				Type:     "random_id",
+				Name:     "suffix",
+				Provider: ProviderRef{LocalName: "random"},
+			},
+		},
+		DataSources: map[string]*DataSource{
+			"data.aws_ami.ubuntu": {
+				Type:     "aws_ami",
+				Name:     "ubuntu",
+				Provider: ProviderRef{LocalName: "aws"},
+			},
+		},
+		EphemeralResources: map[string]*EphemeralResource{
+			"ephemeral.aws_secretsmanager_secret_version.db": {
+				Type:     "aws_secretsmanager_secret_version",
+				Name:     "db",
+				Provider: ProviderRef{LocalName: "aws", Alias: "west"},
+				ForEach:  hcl.StaticExpr(cty.SetVal([]cty.Value{cty.StringVal("primary")}), hcl.Range{}),
+			},
+		},
+		ModuleSources: map[string]*ModuleSource{
+			"module.vpc": {
+				Name:    "vpc",
+				Source:  "terraform-aws-modules/vpc/aws",
+				Version: "5.1.0",
+			},
+			"module.dns": {
+				Name:   "dns",
+				Source: "./modules/dns",
+			},
+		},
+		Variables: map[string]*Variable{
+			"name": {
+				Name:       "name",
+				Type:       cty.DynamicPseudoType,
+				IsNullable: true,
+			},
+			"tags": {
+				Name:         "tags",
+				Type:         cty.Map(cty.String),
+				DefaultValue: cty.MapValEmpty(cty.String),
+				IsNullable:   true,
+			},
+			"token": {
+				Name:        "token",
+				Type:        cty.String,
+				IsSensitive: true,
+			},
+		},
+		Outputs: map[string]*Output{
+			"web_ids": {Name: "web_ids"},
+			"token":   {Name: "token", IsSensitive: true},
+		},
+	}
+
+	var buf strings.Builder
+	if err := Dump(&buf, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	goldenPath := filepath.Join("testdata", "dump.golden")
+	if *update {
+		if err := ioutil.WriteFile(goldenPath, []byte(buf.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(expected), buf.String()); diff != "" {
+		t.Fatalf("unexpected dump (run with -update to accept): %s", diff)
+	}
+}
+
+func mustConstraints(t *testing.T, vc string) version.Constraints {
+	c, err := version.NewConstraint(vc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
@@ -0,0 +1,53 @@
+package module
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// ValidateEphemeralOutputs returns a warning for each reference
+// to an ephemeral variable found in the value of an output
+// which is not itself declared as ephemeral, sorted by output
+// and variable name.
+//
+// As with FindSensitiveLeaks, only direct references are considered.
+func ValidateEphemeralOutputs(meta *Meta) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	outputNames := make([]string, 0, len(meta.Outputs))
+	for name := range meta.Outputs {
+		outputNames = append(outputNames, name)
+	}
+	sort.Strings(outputNames)
+
+	for _, name := range outputNames {
+		o := meta.Outputs[name]
+		if o.Ephemeral || o.Value == nil {
+			continue
+		}
+
+		refs := variableReferences(o.Value)
+		sort.SliceStable(refs, func(i, j int) bool {
+			return refs[i].Name < refs[j].Name
+		})
+
+		for _, ref := range refs {
+			v, ok := meta.Variables[ref.Name]
+			if !ok || !v.Ephemeral {
+				continue
+			}
+
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Ephemeral value in non-ephemeral output",
+				Detail: fmt.Sprintf("Output %q refers to ephemeral variable %q, but is not declared as ephemeral. "+
+					"Ephemeral values are not persisted and cannot be used in regular outputs.", o.Name, v.Name),
+				Subject: ref.Range.Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,34 @@
+package module
+
+import (
+	"fmt"
+)
+
+// FileReadError represents a failure to read a configuration
+// file or a module directory, as opposed to a failure
+// to parse or decode its contents
+type FileReadError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileReadError) Error() string {
+	return fmt.Sprintf("failed to read %s: %s", e.Path, e.Err)
+}
+
+func (e *FileReadError) Unwrap() error {
+	return e.Err
+}
+
+// ErrIncompatibleSnapshot is returned when unmarshaling a serialized
+// Meta of a different SchemaVersion, e.g. one cached on disk
+// by an older version of this package
+type ErrIncompatibleSnapshot struct {
+	SchemaVersion   int
+	ExpectedVersion int
+}
+
+func (e *ErrIncompatibleSnapshot) Error() string {
+	return fmt.Sprintf("incompatible snapshot of schema version %d, expected %d",
+		e.SchemaVersion, e.ExpectedVersion)
+}
@@ -0,0 +1,32 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Import represents an import block, used for config-driven import
+type Import struct {
+	// To is the address of the resource to import into.
+	// It is kept unresolved, as the resource may not be declared (yet).
+	// Any dynamic instance key (e.g. [each.key]) is not part of the traversal.
+	To hcl.Traversal
+
+	// ID and Identity are mutually exclusive ways to identify
+	// the remote object, where Identity is an object expression
+	// used by identity-based import (Terraform 1.12+).
+	// Whichever wasn't declared is nil.
+	ID       hcl.Expression
+	Identity hcl.Expression
+
+	// Provider is empty unless explicitly declared
+	Provider ProviderRef
+
+	// ForEach is nil unless declared
+	ForEach hcl.Expression
+}
+
+// UsesIdentity returns true if the remote object
+// is identified by identity rather than by ID
+func (i *Import) UsesIdentity() bool {
+	return i.Identity != nil
+}
@@ -0,0 +1,29 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Lifecycle represents the lifecycle block of a resource
+// or a data source, where data sources only support
+// preconditions and postconditions
+type Lifecycle struct {
+	CreateBeforeDestroy bool
+	PreventDestroy      bool
+
+	// IgnoreChanges contains (relative) references to attributes,
+	// such as tags, unless IgnoreAllChanges is true
+	IgnoreChanges    []hcl.Traversal
+	IgnoreAllChanges bool
+
+	ReplaceTriggeredBy []hcl.Expression
+
+	Preconditions  []*CheckRule
+	Postconditions []*CheckRule
+}
+
+// CheckRule represents a precondition or postcondition block
+type CheckRule struct {
+	Condition    hcl.Expression
+	ErrorMessage hcl.Expression
+}
@@ -1,7 +1,13 @@
 package module
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/terraform-registry-address"
 )
 
@@ -11,6 +17,89 @@ type Meta struct {
 	ProviderReferences   map[ProviderRef]tfaddr.Provider
 	ProviderRequirements map[tfaddr.Provider]version.Constraints
 	CoreRequirements     version.Constraints
+
+	// RequiredCore represents the raw required_version constraints
+	// in order of declaration, or nil if none were declared
+	RequiredCore []string
+
+	// Experiments represents the experiments opted into
+	// via the experiments argument of the terraform block
+	Experiments []string
+
+	// RequiredProviders represents the provider requirements
+	// as declared, keyed by their local names
+	RequiredProviders map[string]*ProviderRequirement
+
+	// ProviderConfigs represents provider configurations declared
+	// via provider blocks, sorted by local name and alias
+	ProviderConfigs []*ProviderConfig
+
+	Resources     map[string]*Resource
+	DataSources   map[string]*DataSource
+	ModuleSources map[string]*ModuleSource
+	Variables     map[string]*Variable
+	Outputs       map[string]*Output
+
+	// EphemeralResources represents ephemeral blocks,
+	// keyed by their map keys (ephemeral.TYPE.NAME)
+	EphemeralResources map[string]*EphemeralResource
+
+	// Backend and Cloud represent the backend and cloud blocks
+	// of the terraform block respectively. Only one of them
+	// may be declared across all files and they're nil unless
+	// declared. They are not serialized.
+	Backend *Backend
+	Cloud   *CloudConfig
+
+	// Checks represents check blocks, keyed by their names.
+	// They are not serialized.
+	Checks map[string]*Check
+
+	// Locals represents expressions of local values,
+	// keyed by their names. They are not serialized.
+	Locals map[string]hcl.Expression
+
+	// MovedBlocks, Imports and Removed represent moved, import
+	// and removed blocks respectively, in order of declaration,
+	// with files in lexical order. They are not serialized.
+	MovedBlocks []*Moved
+	Imports     []*Import
+	Removed     []*Removed
+
+	// ProviderMeta represents the bodies of provider_meta
+	// blocks, keyed by the provider local name
+	ProviderMeta map[string]hcl.Body
+
+	// Diagnostics contains all diagnostics produced when decoding
+	// the module, as also returned alongside Meta
+	Diagnostics hcl.Diagnostics
+}
+
+// ProviderConfig represents a provider block
+type ProviderConfig struct {
+	LocalName string
+	Alias     string
+
+	// AliasUnknown is true if the alias is not a literal string
+	// (e.g. a variable reference) and cannot be determined
+	// without evaluation, in which case Alias is empty
+	AliasUnknown bool
+
+	// NestedBlockTypes contains sorted types of blocks nested
+	// in the provider block (e.g. assume_role), or nil if there
+	// are none or if the block was declared in JSON
+	NestedBlockTypes []string
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// Ref returns the reference to the provider configuration
+func (pc *ProviderConfig) Ref() ProviderRef {
+	return ProviderRef{
+		LocalName: pc.LocalName,
+		Alias:     pc.Alias,
+	}
 }
 
 type ProviderRef struct {
@@ -20,3 +109,207 @@ type ProviderRef struct {
 	// configuration this address refers to.
 	Alias string
 }
+
+// String returns the reference in the local or local.alias
+// form, as used in provider arguments of resources
+func (pr ProviderRef) String() string {
+	if pr.Alias == "" {
+		return pr.LocalName
+	}
+	return fmt.Sprintf("%s.%s", pr.LocalName, pr.Alias)
+}
+
+// ParseProviderRef parses the given reference in the local
+// or local.alias form, i.e. the inverse of ProviderRef.String
+func ParseProviderRef(raw string) (ProviderRef, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) > 2 {
+		return ProviderRef{}, fmt.Errorf("%q: provider reference must have at most 2 segments, %d given",
+			raw, len(parts))
+	}
+	for _, part := range parts {
+		if !hclsyntax.ValidIdentifier(part) {
+			return ProviderRef{}, fmt.Errorf("%q: invalid provider reference segment %q", raw, part)
+		}
+	}
+
+	ref := ProviderRef{
+		LocalName: parts[0],
+	}
+	if len(parts) == 2 {
+		ref.Alias = parts[1]
+	}
+	return ref, nil
+}
+
+// ProviderAliases returns sorted aliases of provider configurations
+// of the given local name, including the empty alias
+// if there is a default (unaliased) configuration.
+// Configurations with unknown aliases are skipped.
+func (m *Meta) ProviderAliases(localName string) []string {
+	aliases := make([]string, 0)
+	for _, cfg := range m.ProviderConfigs {
+		if cfg.LocalName == localName && !cfg.AliasUnknown {
+			aliases = append(aliases, cfg.Alias)
+		}
+	}
+	sort.Strings(aliases)
+
+	return aliases
+}
+
+// ReferencedProviders returns de-duplicated provider references
+// used by resources, data sources and ephemeral resources, sorted by local name and alias.
+//
+// Unlike ProviderReferences, this only contains references which are
+// actually used, including aliases without any provider block.
+func (m *Meta) ReferencedProviders() []ProviderRef {
+	seen := make(map[ProviderRef]struct{}, 0)
+	for _, r := range m.Resources {
+		seen[r.Provider] = struct{}{}
+	}
+	for _, ds := range m.DataSources {
+		seen[ds.Provider] = struct{}{}
+	}
+	for _, er := range m.EphemeralResources {
+		seen[er.Provider] = struct{}{}
+	}
+
+	refs := make([]ProviderRef, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].LocalName != refs[j].LocalName {
+			return refs[i].LocalName < refs[j].LocalName
+		}
+		return refs[i].Alias < refs[j].Alias
+	})
+
+	return refs
+}
+
+// UsesProvider returns true if the provider of the given local name
+// is declared in required_providers, configured in a provider block
+// or used by any resource, data source or ephemeral resource.
+//
+// Aliases are disregarded, i.e. aws.west is the same provider as aws.
+func (m *Meta) UsesProvider(localName string) bool {
+	if ref, err := ParseProviderRef(localName); err == nil {
+		localName = ref.LocalName
+	}
+
+	if _, ok := m.RequiredProviders[localName]; ok {
+		return true
+	}
+	for _, pc := range m.ProviderConfigs {
+		if pc.LocalName == localName {
+			return true
+		}
+	}
+	for _, ref := range m.ReferencedProviders() {
+		if ref.LocalName == localName {
+			return true
+		}
+	}
+	for _, c := range m.Checks {
+		for _, ds := range c.ScopedDataSources {
+			if ds.Provider.LocalName == localName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ModuleProvider represents a provider needed by a module
+type ModuleProvider struct {
+	LocalName string
+	Addr      tfaddr.Provider
+
+	// Required is true if the provider is declared in required_providers
+	// and false if it's only inferred from its usage, e.g. from the type
+	// prefix of a resource or from a provider block
+	Required bool
+}
+
+// Providers returns providers declared in required_providers merged
+// with those inferred from provider blocks, resources, data sources
+// and ephemeral resources, sorted by local name.
+//
+// Inferred providers which are not declared may be candidates
+// for new required_providers entries.
+func (m *Meta) Providers() []ModuleProvider {
+	required := make(map[string]bool, 0)
+	for name, req := range m.RequiredProviders {
+		// entries implied by provider blocks have no declaration
+		required[name] = len(req.DeclRanges) > 0
+	}
+	for _, pc := range m.ProviderConfigs {
+		if _, ok := required[pc.LocalName]; !ok {
+			required[pc.LocalName] = false
+		}
+	}
+	for _, ref := range m.ReferencedProviders() {
+		if _, ok := required[ref.LocalName]; !ok {
+			required[ref.LocalName] = false
+		}
+	}
+	for _, c := range m.Checks {
+		for _, ds := range c.ScopedDataSources {
+			if _, ok := required[ds.Provider.LocalName]; !ok {
+				required[ds.Provider.LocalName] = false
+			}
+		}
+	}
+
+	providers := make([]ModuleProvider, 0, len(required))
+	for name, isRequired := range required {
+		if name == "" {
+			continue
+		}
+		addr, ok := m.ProviderReferences[ProviderRef{LocalName: name}]
+		if !ok || addr.IsZero() {
+			addr = tfaddr.NewLegacyProvider(name)
+		}
+		providers = append(providers, ModuleProvider{
+			LocalName: name,
+			Addr:      addr,
+			Required:  isRequired,
+		})
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].LocalName < providers[j].LocalName
+	})
+
+	return providers
+}
+
+// RequiredCoreConstraints parses and merges the constraints of RequiredCore
+// via ParseCoreConstraints.
+func (m *Meta) RequiredCoreConstraints() (version.Constraints, hcl.Diagnostics) {
+	return ParseCoreConstraints(m.RequiredCore)
+}
+
+// ParseCoreConstraints parses and merges the given required_version
+// constraints, returning diagnostics for any which cannot be parsed.
+// It returns nil constraints if no constraints are given.
+func ParseCoreConstraints(requiredCore []string) (version.Constraints, hcl.Diagnostics) {
+	var constraints version.Constraints
+	var diags hcl.Diagnostics
+
+	for _, rc := range requiredCore {
+		c, err := version.NewConstraint(rc)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unable to parse terraform requirements",
+				Detail:   fmt.Sprintf("Constraint %q is not a valid constraint: %s", rc, err),
+			})
+			continue
+		}
+		constraints = append(constraints, c...)
+	}
+
+	return constraints, diags
+}
@@ -0,0 +1,179 @@
+package module
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+// MetaDiff represents differences between two module metadata,
+// where objects are identified by the keys of the respective maps
+// of Meta, and providers by their addresses.
+type MetaDiff struct {
+	Resources          ObjectDiff
+	DataSources        ObjectDiff
+	EphemeralResources ObjectDiff
+	ModuleCalls        ObjectDiff
+	Providers          ObjectDiff
+	Variables          ObjectDiff
+	Outputs            ObjectDiff
+}
+
+// ObjectDiff contains sorted keys of objects of a single kind
+// which were added, removed or changed
+type ObjectDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// IsEmpty returns true if no objects were added, removed or changed
+func (d ObjectDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// IsEmpty returns true if there are no differences
+func (d MetaDiff) IsEmpty() bool {
+	return d.Resources.IsEmpty() &&
+		d.DataSources.IsEmpty() &&
+		d.EphemeralResources.IsEmpty() &&
+		d.ModuleCalls.IsEmpty() &&
+		d.Providers.IsEmpty() &&
+		d.Variables.IsEmpty() &&
+		d.Outputs.IsEmpty()
+}
+
+// DiffMeta returns objects added, removed or changed between
+// the old and the new metadata, compared the same way as by Equal.
+//
+// A renamed object is reported as removed under the old key
+// and added under the new one. Nil metadata is treated as empty.
+func DiffMeta(old, new *Meta) MetaDiff {
+	if old == nil {
+		old = &Meta{}
+	}
+	if new == nil {
+		new = &Meta{}
+	}
+
+	var diff MetaDiff
+
+	oldResources, newResources := make(map[string]bool, 0), make(map[string]bool, 0)
+	for key := range old.Resources {
+		oldResources[key] = true
+	}
+	for key := range new.Resources {
+		newResources[key] = true
+	}
+	diff.Resources = diffKeys(oldResources, newResources, func(key string) bool {
+		return resourceEqual(old.Resources[key], new.Resources[key])
+	})
+
+	oldDataSources, newDataSources := make(map[string]bool, 0), make(map[string]bool, 0)
+	for key := range old.DataSources {
+		oldDataSources[key] = true
+	}
+	for key := range new.DataSources {
+		newDataSources[key] = true
+	}
+	diff.DataSources = diffKeys(oldDataSources, newDataSources, func(key string) bool {
+		return dataSourceEqual(old.DataSources[key], new.DataSources[key])
+	})
+
+	oldEphemeralResources, newEphemeralResources := make(map[string]bool, 0), make(map[string]bool, 0)
+	for key := range old.EphemeralResources {
+		oldEphemeralResources[key] = true
+	}
+	for key := range new.EphemeralResources {
+		newEphemeralResources[key] = true
+	}
+	diff.EphemeralResources = diffKeys(oldEphemeralResources, newEphemeralResources, func(key string) bool {
+		return ephemeralResourceEqual(old.EphemeralResources[key], new.EphemeralResources[key])
+	})
+
+	oldModules, newModules := make(map[string]bool, 0), make(map[string]bool, 0)
+	for key := range old.ModuleSources {
+		oldModules[key] = true
+	}
+	for key := range new.ModuleSources {
+		newModules[key] = true
+	}
+	diff.ModuleCalls = diffKeys(oldModules, newModules, func(key string) bool {
+		return moduleSourceEqual(old.ModuleSources[key], new.ModuleSources[key])
+	})
+
+	oldProviders, newProviders := make(map[string]bool, 0), make(map[string]bool, 0)
+	for pAddr := range old.ProviderRequirements {
+		oldProviders[pAddr.String()] = true
+	}
+	for pAddr := range new.ProviderRequirements {
+		newProviders[pAddr.String()] = true
+	}
+	diff.Providers = diffKeys(oldProviders, newProviders, func(key string) bool {
+		return constraintsEqual(providerConstraints(old, key), providerConstraints(new, key))
+	})
+
+	oldVariables, newVariables := make(map[string]bool, 0), make(map[string]bool, 0)
+	for key := range old.Variables {
+		oldVariables[key] = true
+	}
+	for key := range new.Variables {
+		newVariables[key] = true
+	}
+	diff.Variables = diffKeys(oldVariables, newVariables, func(key string) bool {
+		return variableEqual(old.Variables[key], new.Variables[key])
+	})
+
+	oldOutputs, newOutputs := make(map[string]bool, 0), make(map[string]bool, 0)
+	for key := range old.Outputs {
+		oldOutputs[key] = true
+	}
+	for key := range new.Outputs {
+		newOutputs[key] = true
+	}
+	diff.Outputs = diffKeys(oldOutputs, newOutputs, func(key string) bool {
+		return outputEqual(old.Outputs[key], new.Outputs[key])
+	})
+
+	return diff
+}
+
+// diffKeys compares the given sets of keys, using equal
+// to tell whether objects present in both sets changed
+func diffKeys(oldKeys, newKeys map[string]bool, equal func(key string) bool) ObjectDiff {
+	diff := ObjectDiff{
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Changed: make([]string, 0),
+	}
+
+	for key := range newKeys {
+		if !oldKeys[key] {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+	for key := range oldKeys {
+		if !newKeys[key] {
+			diff.Removed = append(diff.Removed, key)
+			continue
+		}
+		if !equal(key) {
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+
+	return diff
+}
+
+func providerConstraints(meta *Meta, pAddr string) version.Constraints {
+	for addr, constraints := range meta.ProviderRequirements {
+		if addr.String() == pAddr {
+			return constraints
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	tfaddr "github.com/hashicorp/terraform-registry-address"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDiffMeta(t *testing.T) {
+	awsAddr := tfaddr.NewDefaultProvider("aws")
+	idExpr := hcl.StaticExpr(cty.StringVal("i-123"), hcl.Range{})
+
+	oldMeta := &Meta{
+		ProviderRequirements: map[tfaddr.Provider]version.Constraints{
+			awsAddr: mustConstraints(t, "~> 4.0"),
+		},
+		Resources: map[string]*Resource{
+			"aws_instance.web": {Type: "aws_instance", Name: "web", ProviderAddr: awsAddr},
+		},
+		Outputs: map[string]*Output{
+			"id": {Name: "id", Value: idExpr},
+		},
+	}
+	newMeta := &Meta{
+		ProviderRequirements: map[tfaddr.Provider]version.Constraints{
+			awsAddr: mustConstraints(t, "~> 5.0"),
+		},
+		Resources: map[string]*Resource{
+			"aws_instance.app": {Type: "aws_instance", Name: "app", ProviderAddr: awsAddr},
+		},
+		Outputs: map[string]*Output{
+			"id":  {Name: "id", Value: idExpr},
+			"arn": {Name: "arn", Value: idExpr},
+		},
+	}
+
+	noChange := ObjectDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+	expectedDiff := MetaDiff{
+		Resources: ObjectDiff{
+			Added:   []string{"aws_instance.app"},
+			Removed: []string{"aws_instance.web"},
+			Changed: []string{},
+		},
+		DataSources:        noChange,
+		EphemeralResources: noChange,
+		ModuleCalls:        noChange,
+		Providers: ObjectDiff{
+			Added:   []string{},
+			Removed: []string{},
+			Changed: []string{"registry.terraform.io/hashicorp/aws"},
+		},
+		Variables: noChange,
+		Outputs: ObjectDiff{
+			Added:   []string{"arn"},
+			Removed: []string{},
+			Changed: []string{},
+		},
+	}
+
+	diff := DiffMeta(oldMeta, newMeta)
+	if d := cmp.Diff(expectedDiff, diff); d != "" {
+		t.Fatalf("unexpected diff: %s", d)
+	}
+
+	if !DiffMeta(oldMeta, oldMeta).IsEmpty() {
+		t.Fatal("expected no differences between the same metadata")
+	}
+	if !DiffMeta(nil, &Meta{}).IsEmpty() {
+		t.Fatal("expected nil metadata to be treated as empty")
+	}
+}
+
+func TestDiffMeta_ephemeralResources(t *testing.T) {
+	oldMeta := &Meta{
+		EphemeralResources: map[string]*EphemeralResource{
+			"ephemeral.aws_secretsmanager_secret_version.db": {
+				Type: "aws_secretsmanager_secret_version",
+				Name: "db",
+			},
+		},
+	}
+	newMeta := &Meta{
+		EphemeralResources: map[string]*EphemeralResource{
+			"ephemeral.aws_secretsmanager_secret_version.db": {
+				Type:  "aws_secretsmanager_secret_version",
+				Name:  "db",
+				Count: hcl.StaticExpr(cty.NumberIntVal(2), hcl.Range{}),
+			},
+		},
+	}
+
+	if oldMeta.Equal(newMeta) {
+		t.Fatal("expected metadata not to be equal")
+	}
+
+	diff := DiffMeta(oldMeta, newMeta)
+	if diff.IsEmpty() {
+		t.Fatal("expected differences in ephemeral resources")
+	}
+	expectedDiff := ObjectDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{"ephemeral.aws_secretsmanager_secret_version.db"},
+	}
+	if d := cmp.Diff(expectedDiff, diff.EphemeralResources); d != "" {
+		t.Fatalf("unexpected ephemeral resources diff: %s", d)
+	}
+}
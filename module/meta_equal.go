@@ -0,0 +1,193 @@
+package module
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Equal returns true if the receiver and the other metadata declare
+// the same provider and core requirements, resources, data sources,
+// ephemeral resources, module calls, variables and outputs.
+//
+// Source ranges are ignored, so moving blocks around or changing
+// comments doesn't affect equality. Expressions (such as count or output
+// values) cannot be compared without evaluation and are only compared
+// by their presence.
+func (m *Meta) Equal(other *Meta) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+
+	if len(m.ProviderRequirements) != len(other.ProviderRequirements) {
+		return false
+	}
+	for pAddr, constraints := range m.ProviderRequirements {
+		otherConstraints, ok := other.ProviderRequirements[pAddr]
+		if !ok || !constraintsEqual(constraints, otherConstraints) {
+			return false
+		}
+	}
+
+	if !constraintsEqual(m.CoreRequirements, other.CoreRequirements) {
+		return false
+	}
+
+	if len(m.Resources) != len(other.Resources) {
+		return false
+	}
+	for key, r := range m.Resources {
+		otherR, ok := other.Resources[key]
+		if !ok || !resourceEqual(r, otherR) {
+			return false
+		}
+	}
+
+	if len(m.DataSources) != len(other.DataSources) {
+		return false
+	}
+	for key, ds := range m.DataSources {
+		otherDs, ok := other.DataSources[key]
+		if !ok || !dataSourceEqual(ds, otherDs) {
+			return false
+		}
+	}
+
+	if len(m.EphemeralResources) != len(other.EphemeralResources) {
+		return false
+	}
+	for key, er := range m.EphemeralResources {
+		otherEr, ok := other.EphemeralResources[key]
+		if !ok || !ephemeralResourceEqual(er, otherEr) {
+			return false
+		}
+	}
+
+	if len(m.ModuleSources) != len(other.ModuleSources) {
+		return false
+	}
+	for key, ms := range m.ModuleSources {
+		otherMs, ok := other.ModuleSources[key]
+		if !ok || !moduleSourceEqual(ms, otherMs) {
+			return false
+		}
+	}
+
+	if len(m.Variables) != len(other.Variables) {
+		return false
+	}
+	for key, v := range m.Variables {
+		otherV, ok := other.Variables[key]
+		if !ok || !variableEqual(v, otherV) {
+			return false
+		}
+	}
+
+	if len(m.Outputs) != len(other.Outputs) {
+		return false
+	}
+	for key, o := range m.Outputs {
+		otherO, ok := other.Outputs[key]
+		if !ok || !outputEqual(o, otherO) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func constraintsEqual(a, b version.Constraints) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].String() != b[i].String() {
+			return false
+		}
+	}
+	return true
+}
+
+func resourceEqual(a, b *Resource) bool {
+	return a.Type == b.Type &&
+		a.Name == b.Name &&
+		a.Provider == b.Provider &&
+		a.ProviderAddr == b.ProviderAddr &&
+		(a.Count == nil) == (b.Count == nil) &&
+		(a.ForEach == nil) == (b.ForEach == nil) &&
+		traversalsEqual(a.DependsOn, b.DependsOn)
+}
+
+func dataSourceEqual(a, b *DataSource) bool {
+	return a.Type == b.Type &&
+		a.Name == b.Name &&
+		a.Provider == b.Provider &&
+		a.ProviderAddr == b.ProviderAddr &&
+		(a.Count == nil) == (b.Count == nil) &&
+		(a.ForEach == nil) == (b.ForEach == nil) &&
+		traversalsEqual(a.DependsOn, b.DependsOn)
+}
+
+func ephemeralResourceEqual(a, b *EphemeralResource) bool {
+	return a.Type == b.Type &&
+		a.Name == b.Name &&
+		a.Provider == b.Provider &&
+		a.ProviderAddr == b.ProviderAddr &&
+		(a.Count == nil) == (b.Count == nil) &&
+		(a.ForEach == nil) == (b.ForEach == nil) &&
+		traversalsEqual(a.DependsOn, b.DependsOn)
+}
+
+func moduleSourceEqual(a, b *ModuleSource) bool {
+	if a.Name != b.Name || a.Source != b.Source || a.Version != b.Version {
+		return false
+	}
+	if len(a.Providers) != len(b.Providers) {
+		return false
+	}
+	for childRef, ref := range a.Providers {
+		if otherRef, ok := b.Providers[childRef]; !ok || ref != otherRef {
+			return false
+		}
+	}
+	return traversalsEqual(a.DependsOn, b.DependsOn)
+}
+
+func variableEqual(a, b *Variable) bool {
+	if a.Name != b.Name ||
+		a.Description != b.Description ||
+		a.IsSensitive != b.IsSensitive ||
+		a.IsNullable != b.IsNullable ||
+		a.Ephemeral != b.Ephemeral ||
+		len(a.Validations) != len(b.Validations) {
+		return false
+	}
+	if !a.Type.Equals(b.Type) {
+		return false
+	}
+	if a.DefaultValue == cty.NilVal || b.DefaultValue == cty.NilVal {
+		return a.DefaultValue == cty.NilVal && b.DefaultValue == cty.NilVal
+	}
+	return a.DefaultValue.RawEquals(b.DefaultValue)
+}
+
+func outputEqual(a, b *Output) bool {
+	return a.Name == b.Name &&
+		a.Description == b.Description &&
+		a.IsSensitive == b.IsSensitive &&
+		a.Ephemeral == b.Ephemeral &&
+		(a.Value == nil) == (b.Value == nil) &&
+		traversalsEqual(a.DependsOn, b.DependsOn)
+}
+
+func traversalsEqual(a, b []hcl.Traversal) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if traversalSource(a[i]) != traversalSource(b[i]) {
+			return false
+		}
+	}
+	return true
+}
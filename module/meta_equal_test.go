@@ -0,0 +1,110 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	tfaddr "github.com/hashicorp/terraform-registry-address"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestMeta_Equal(t *testing.T) {
+	awsAddr := tfaddr.NewDefaultProvider("aws")
+	newMeta := func() *Meta {
+		return &Meta{
+			Path:             "path",
+			CoreRequirements: mustConstraints(t, ">= 1.0"),
+			ProviderRequirements: map[tfaddr.Provider]version.Constraints{
+				awsAddr: mustConstraints(t, "~> 4.0"),
+			},
+			Resources: map[string]*Resource{
+				"aws_instance.web": {
+					Type:         "aws_instance",
+					Name:         "web",
+					Provider:     ProviderRef{LocalName: "aws"},
+					ProviderAddr: awsAddr,
+					Count:        hcl.StaticExpr(cty.NumberIntVal(2), hcl.Range{}),
+				},
+			},
+			DataSources:        map[string]*DataSource{},
+			EphemeralResources: map[string]*EphemeralResource{},
+			ModuleSources: map[string]*ModuleSource{
+				"module.network": {Name: "network", Source: "./network"},
+			},
+			Variables: map[string]*Variable{
+				"name": {
+					Name:         "name",
+					Type:         cty.String,
+					DefaultValue: cty.StringVal("web"),
+					IsNullable:   true,
+				},
+			},
+			Outputs: map[string]*Output{
+				"id": {
+					Name:  "id",
+					Value: hcl.StaticExpr(cty.StringVal("i-123"), hcl.Range{}),
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		modify   func(*Meta)
+		expected bool
+	}{
+		{
+			"ranges only",
+			func(m *Meta) {
+				m.Path = "other"
+				m.Resources["aws_instance.web"].DeclRange = hcl.Range{
+					Filename: "main.tf",
+					Start:    hcl.Pos{Line: 2, Column: 1, Byte: 26},
+					End:      hcl.Pos{Line: 2, Column: 31, Byte: 56},
+				}
+			},
+			true,
+		},
+		{
+			"provider version bump",
+			func(m *Meta) {
+				m.ProviderRequirements[awsAddr] = mustConstraints(t, "~> 5.0")
+			},
+			false,
+		},
+		{
+			"count removed",
+			func(m *Meta) {
+				m.Resources["aws_instance.web"].Count = nil
+			},
+			false,
+		},
+		{
+			"variable default",
+			func(m *Meta) {
+				m.Variables["name"].DefaultValue = cty.StringVal("api")
+			},
+			false,
+		},
+		{
+			"module source",
+			func(m *Meta) {
+				m.ModuleSources["module.network"].Source = "./vpc"
+			},
+			false,
+		},
+	}
+
+	meta := newMeta()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			otherMeta := newMeta()
+			tc.modify(otherMeta)
+
+			if equal := meta.Equal(otherMeta); equal != tc.expected {
+				t.Fatalf("expected Equal to return %t, given %t", tc.expected, equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,753 @@
+package module
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-registry-address"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// MetaFormatVersion is the version of the JSON representation
+// of Meta, which is bumped on any backwards incompatible change
+const MetaFormatVersion = 1
+
+// SchemaVersion is the version of the decoded metadata, which is bumped
+// whenever decoding changes such that a snapshot of the same configuration
+// would differ, e.g. when new fields are populated. Unlike MetaFormatVersion
+// it doesn't imply any change of the JSON representation, but snapshots
+// of other versions are still rejected as stale.
+const SchemaVersion = 2
+
+// metaJSON is the stable JSON representation of Meta.
+//
+// Expressions (such as count, for_each, output values or conditions
+// of variable validations) cannot be serialized and are represented
+// by their source range only. After unmarshaling they're placeholders
+// of an unknown value, such that their presence is retained.
+//
+// Lifecycle and dynamic blocks of resources and data sources, bodies
+// of resources, data sources and provider_meta blocks, backend and cloud
+// blocks, checks, locals, moved, import and removed blocks
+// and diagnostics are omitted entirely.
+type metaJSON struct {
+	FormatVersion int    `json:"format_version"`
+	SchemaVersion int    `json:"schema_version"`
+	Path          string `json:"path"`
+
+	ProviderReferences   []providerReferenceJSON `json:"provider_references"`
+	ProviderRequirements map[string][]string     `json:"provider_requirements"`
+	CoreRequirements     []string                `json:"core_requirements,omitempty"`
+	RequiredCore         []string                `json:"required_core,omitempty"`
+	Experiments          []string                `json:"experiments"`
+
+	RequiredProviders map[string]*providerRequirementJSON `json:"required_providers"`
+	ProviderConfigs   []providerConfigJSON                `json:"provider_configs"`
+
+	Resources     map[string]*resourceJSON     `json:"resources"`
+	DataSources   map[string]*resourceJSON     `json:"data_sources"`
+	ModuleSources map[string]*moduleSourceJSON `json:"module_sources"`
+	Variables     map[string]*variableJSON     `json:"variables"`
+	Outputs       map[string]*outputJSON       `json:"outputs"`
+
+	EphemeralResources map[string]*resourceJSON `json:"ephemeral_resources,omitempty"`
+}
+
+type providerRefJSON struct {
+	LocalName string `json:"local_name"`
+	Alias     string `json:"alias,omitempty"`
+}
+
+type providerReferenceJSON struct {
+	providerRefJSON
+	Provider string `json:"provider"`
+}
+
+type providerConfigJSON struct {
+	providerRefJSON
+	AliasUnknown     bool      `json:"alias_unknown,omitempty"`
+	NestedBlockTypes []string  `json:"nested_block_types,omitempty"`
+	DeclRange        hcl.Range `json:"decl_range"`
+}
+
+type providerRequirementJSON struct {
+	Source               string            `json:"source,omitempty"`
+	VersionConstraints   []string          `json:"version_constraints,omitempty"`
+	ConfigurationAliases []providerRefJSON `json:"configuration_aliases,omitempty"`
+	DeclRanges           []hcl.Range       `json:"decl_ranges,omitempty"`
+}
+
+type traversalJSON struct {
+	Traversal string    `json:"traversal"`
+	Range     hcl.Range `json:"range"`
+}
+
+type resourceJSON struct {
+	Type         string          `json:"type"`
+	Name         string          `json:"name"`
+	Provider     providerRefJSON `json:"provider"`
+	ProviderAddr string          `json:"provider_addr,omitempty"`
+	CountRange   *hcl.Range      `json:"count_range,omitempty"`
+	ForEachRange *hcl.Range      `json:"for_each_range,omitempty"`
+	DependsOn    []traversalJSON `json:"depends_on,omitempty"`
+	DeclRange    hcl.Range       `json:"decl_range"`
+
+	Provisioners  []provisionerJSON `json:"provisioners,omitempty"`
+	HasConnection bool              `json:"has_connection,omitempty"`
+}
+
+type provisionerJSON struct {
+	Type          string    `json:"type"`
+	WhenDestroy   bool      `json:"when_destroy,omitempty"`
+	HasConnection bool      `json:"has_connection,omitempty"`
+	DeclRange     hcl.Range `json:"decl_range"`
+}
+
+type checkRuleJSON struct {
+	ConditionRange    *hcl.Range `json:"condition_range,omitempty"`
+	ErrorMessageRange *hcl.Range `json:"error_message_range,omitempty"`
+}
+
+type moduleSourceJSON struct {
+	Name      string                     `json:"name"`
+	Source    string                     `json:"source"`
+	Version   string                     `json:"version,omitempty"`
+	DependsOn []traversalJSON            `json:"depends_on,omitempty"`
+	Providers map[string]providerRefJSON `json:"providers,omitempty"`
+	DeclRange hcl.Range                  `json:"decl_range"`
+}
+
+type variableJSON struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Type        json.RawMessage `json:"type"`
+	DefaultType json.RawMessage `json:"default_type,omitempty"`
+	Default     json.RawMessage `json:"default,omitempty"`
+	IsSensitive bool            `json:"sensitive"`
+	IsNullable  bool            `json:"nullable"`
+	Ephemeral   bool            `json:"ephemeral,omitempty"`
+	Validations []checkRuleJSON `json:"validations,omitempty"`
+	DeclRange   hcl.Range       `json:"decl_range"`
+}
+
+type outputJSON struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	IsSensitive bool            `json:"sensitive"`
+	Ephemeral   bool            `json:"ephemeral,omitempty"`
+	ValueRange  *hcl.Range      `json:"value_range,omitempty"`
+	DependsOn   []traversalJSON `json:"depends_on,omitempty"`
+	DeclRange   hcl.Range       `json:"decl_range"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (m *Meta) MarshalJSON() ([]byte, error) {
+	mj := &metaJSON{
+		FormatVersion:        MetaFormatVersion,
+		SchemaVersion:        SchemaVersion,
+		Path:                 m.Path,
+		ProviderReferences:   make([]providerReferenceJSON, 0, len(m.ProviderReferences)),
+		ProviderRequirements: make(map[string][]string, len(m.ProviderRequirements)),
+		RequiredProviders:    make(map[string]*providerRequirementJSON, len(m.RequiredProviders)),
+		ProviderConfigs:      make([]providerConfigJSON, 0, len(m.ProviderConfigs)),
+		Resources:            make(map[string]*resourceJSON, len(m.Resources)),
+		DataSources:          make(map[string]*resourceJSON, len(m.DataSources)),
+		ModuleSources:        make(map[string]*moduleSourceJSON, len(m.ModuleSources)),
+		Variables:            make(map[string]*variableJSON, len(m.Variables)),
+		Outputs:              make(map[string]*outputJSON, len(m.Outputs)),
+		EphemeralResources:   make(map[string]*resourceJSON, len(m.EphemeralResources)),
+	}
+
+	for ref, pAddr := range m.ProviderReferences {
+		mj.ProviderReferences = append(mj.ProviderReferences, providerReferenceJSON{
+			providerRefJSON: providerRefToJSON(ref),
+			Provider:        providerToJSON(pAddr),
+		})
+	}
+	// keep the output stable
+	sort.Slice(mj.ProviderReferences, func(i, j int) bool {
+		a, b := mj.ProviderReferences[i], mj.ProviderReferences[j]
+		if a.LocalName != b.LocalName {
+			return a.LocalName < b.LocalName
+		}
+		return a.Alias < b.Alias
+	})
+
+	for pAddr, constraints := range m.ProviderRequirements {
+		mj.ProviderRequirements[providerToJSON(pAddr)] = constraintsToJSON(constraints)
+	}
+	mj.CoreRequirements = constraintsToJSON(m.CoreRequirements)
+	mj.RequiredCore = m.RequiredCore
+	mj.Experiments = m.Experiments
+
+	for name, req := range m.RequiredProviders {
+		rj := &providerRequirementJSON{
+			Source:             req.Source,
+			VersionConstraints: req.VersionConstraints,
+			DeclRanges:         req.DeclRanges,
+		}
+		for _, alias := range req.ConfigurationAliases {
+			rj.ConfigurationAliases = append(rj.ConfigurationAliases, providerRefToJSON(alias))
+		}
+		mj.RequiredProviders[name] = rj
+	}
+
+	for _, cfg := range m.ProviderConfigs {
+		mj.ProviderConfigs = append(mj.ProviderConfigs, providerConfigJSON{
+			providerRefJSON:  providerRefToJSON(cfg.Ref()),
+			AliasUnknown:     cfg.AliasUnknown,
+			NestedBlockTypes: cfg.NestedBlockTypes,
+			DeclRange:        cfg.DeclRange,
+		})
+	}
+
+	for key, r := range m.Resources {
+		mj.Resources[key] = &resourceJSON{
+			Type:          r.Type,
+			Name:          r.Name,
+			Provider:      providerRefToJSON(r.Provider),
+			ProviderAddr:  providerToJSON(r.ProviderAddr),
+			CountRange:    exprRange(r.Count),
+			ForEachRange:  exprRange(r.ForEach),
+			DependsOn:     traversalsToJSON(r.DependsOn),
+			DeclRange:     r.DeclRange,
+			Provisioners:  provisionersToJSON(r.Provisioners),
+			HasConnection: r.HasConnection,
+		}
+	}
+
+	for key, ds := range m.DataSources {
+		mj.DataSources[key] = &resourceJSON{
+			Type:         ds.Type,
+			Name:         ds.Name,
+			Provider:     providerRefToJSON(ds.Provider),
+			ProviderAddr: providerToJSON(ds.ProviderAddr),
+			CountRange:   exprRange(ds.Count),
+			ForEachRange: exprRange(ds.ForEach),
+			DependsOn:    traversalsToJSON(ds.DependsOn),
+			DeclRange:    ds.DeclRange,
+		}
+	}
+
+	for key, er := range m.EphemeralResources {
+		mj.EphemeralResources[key] = &resourceJSON{
+			Type:         er.Type,
+			Name:         er.Name,
+			Provider:     providerRefToJSON(er.Provider),
+			ProviderAddr: providerToJSON(er.ProviderAddr),
+			CountRange:   exprRange(er.Count),
+			ForEachRange: exprRange(er.ForEach),
+			DependsOn:    traversalsToJSON(er.DependsOn),
+			DeclRange:    er.DeclRange,
+		}
+	}
+
+	for key, ms := range m.ModuleSources {
+		msj := &moduleSourceJSON{
+			Name:      ms.Name,
+			Source:    ms.Source,
+			Version:   ms.Version,
+			DependsOn: traversalsToJSON(ms.DependsOn),
+			DeclRange: ms.DeclRange,
+		}
+		if len(ms.Providers) > 0 {
+			msj.Providers = make(map[string]providerRefJSON, len(ms.Providers))
+			for childRef, ref := range ms.Providers {
+				msj.Providers[childRef] = providerRefToJSON(ref)
+			}
+		}
+		mj.ModuleSources[key] = msj
+	}
+
+	for key, v := range m.Variables {
+		vj, err := variableToJSON(v)
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %w", v.Name, err)
+		}
+		mj.Variables[key] = vj
+	}
+
+	for key, o := range m.Outputs {
+		mj.Outputs[key] = &outputJSON{
+			Name:        o.Name,
+			Description: o.Description,
+			IsSensitive: o.IsSensitive,
+			Ephemeral:   o.Ephemeral,
+			ValueRange:  exprRange(o.Value),
+			DependsOn:   traversalsToJSON(o.DependsOn),
+			DeclRange:   o.DeclRange,
+		}
+	}
+
+	return json.Marshal(mj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+//
+// Expressions are restored as placeholders of an unknown value
+// at their original source range.
+func (m *Meta) UnmarshalJSON(b []byte) error {
+	var mj metaJSON
+	err := json.Unmarshal(b, &mj)
+	if err != nil {
+		return err
+	}
+
+	if mj.FormatVersion != MetaFormatVersion {
+		return fmt.Errorf("unsupported format version %d, expected %d",
+			mj.FormatVersion, MetaFormatVersion)
+	}
+	if mj.SchemaVersion != SchemaVersion {
+		return &ErrIncompatibleSnapshot{
+			SchemaVersion:   mj.SchemaVersion,
+			ExpectedVersion: SchemaVersion,
+		}
+	}
+
+	meta := Meta{
+		Path:                 mj.Path,
+		Experiments:          mj.Experiments,
+		RequiredCore:         mj.RequiredCore,
+		ProviderReferences:   make(map[ProviderRef]tfaddr.Provider, len(mj.ProviderReferences)),
+		ProviderRequirements: make(map[tfaddr.Provider]version.Constraints, len(mj.ProviderRequirements)),
+		RequiredProviders:    make(map[string]*ProviderRequirement, len(mj.RequiredProviders)),
+		ProviderConfigs:      make([]*ProviderConfig, 0, len(mj.ProviderConfigs)),
+		Resources:            make(map[string]*Resource, len(mj.Resources)),
+		DataSources:          make(map[string]*DataSource, len(mj.DataSources)),
+		ModuleSources:        make(map[string]*ModuleSource, len(mj.ModuleSources)),
+		Variables:            make(map[string]*Variable, len(mj.Variables)),
+		Outputs:              make(map[string]*Output, len(mj.Outputs)),
+		EphemeralResources:   make(map[string]*EphemeralResource, len(mj.EphemeralResources)),
+		Checks:               make(map[string]*Check, 0),
+		Locals:               make(map[string]hcl.Expression, 0),
+		MovedBlocks:          make([]*Moved, 0),
+		Imports:              make([]*Import, 0),
+		Removed:              make([]*Removed, 0),
+		ProviderMeta:         make(map[string]hcl.Body, 0),
+	}
+
+	for _, rj := range mj.ProviderReferences {
+		pAddr, err := providerFromJSON(rj.Provider)
+		if err != nil {
+			return err
+		}
+		meta.ProviderReferences[providerRefFromJSON(rj.providerRefJSON)] = pAddr
+	}
+
+	for rawAddr, rawConstraints := range mj.ProviderRequirements {
+		pAddr, err := providerFromJSON(rawAddr)
+		if err != nil {
+			return err
+		}
+		constraints, err := constraintsFromJSON(rawConstraints)
+		if err != nil {
+			return err
+		}
+		meta.ProviderRequirements[pAddr] = constraints
+	}
+
+	if len(mj.CoreRequirements) > 0 {
+		meta.CoreRequirements, err = constraintsFromJSON(mj.CoreRequirements)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, rj := range mj.RequiredProviders {
+		req := &ProviderRequirement{
+			Source:             rj.Source,
+			VersionConstraints: rj.VersionConstraints,
+			DeclRanges:         rj.DeclRanges,
+		}
+		if len(rj.DeclRanges) > 0 {
+			req.DeclRange = rj.DeclRanges[0]
+		}
+		for _, alias := range rj.ConfigurationAliases {
+			req.ConfigurationAliases = append(req.ConfigurationAliases, providerRefFromJSON(alias))
+		}
+		meta.RequiredProviders[name] = req
+	}
+
+	for _, cj := range mj.ProviderConfigs {
+		meta.ProviderConfigs = append(meta.ProviderConfigs, &ProviderConfig{
+			LocalName:        cj.LocalName,
+			Alias:            cj.Alias,
+			AliasUnknown:     cj.AliasUnknown,
+			NestedBlockTypes: cj.NestedBlockTypes,
+			DeclRange:        cj.DeclRange,
+		})
+	}
+
+	for key, rj := range mj.Resources {
+		deps, err := traversalsFromJSON(rj.DependsOn)
+		if err != nil {
+			return err
+		}
+		pAddr, err := providerFromJSON(rj.ProviderAddr)
+		if err != nil {
+			return err
+		}
+		meta.Resources[key] = &Resource{
+			Type:          rj.Type,
+			Name:          rj.Name,
+			Provider:      providerRefFromJSON(rj.Provider),
+			ProviderAddr:  pAddr,
+			Count:         exprFromRange(rj.CountRange),
+			ForEach:       exprFromRange(rj.ForEachRange),
+			DependsOn:     deps,
+			Provisioners:  provisionersFromJSON(rj.Provisioners),
+			HasConnection: rj.HasConnection,
+			DeclRange:     rj.DeclRange,
+		}
+	}
+
+	for key, dj := range mj.DataSources {
+		deps, err := traversalsFromJSON(dj.DependsOn)
+		if err != nil {
+			return err
+		}
+		pAddr, err := providerFromJSON(dj.ProviderAddr)
+		if err != nil {
+			return err
+		}
+		meta.DataSources[key] = &DataSource{
+			Type:         dj.Type,
+			Name:         dj.Name,
+			Provider:     providerRefFromJSON(dj.Provider),
+			ProviderAddr: pAddr,
+			Count:        exprFromRange(dj.CountRange),
+			ForEach:      exprFromRange(dj.ForEachRange),
+			DependsOn:    deps,
+			DeclRange:    dj.DeclRange,
+		}
+	}
+
+	for key, ej := range mj.EphemeralResources {
+		deps, err := traversalsFromJSON(ej.DependsOn)
+		if err != nil {
+			return err
+		}
+		pAddr, err := providerFromJSON(ej.ProviderAddr)
+		if err != nil {
+			return err
+		}
+		meta.EphemeralResources[key] = &EphemeralResource{
+			Type:         ej.Type,
+			Name:         ej.Name,
+			Provider:     providerRefFromJSON(ej.Provider),
+			ProviderAddr: pAddr,
+			Count:        exprFromRange(ej.CountRange),
+			ForEach:      exprFromRange(ej.ForEachRange),
+			DependsOn:    deps,
+			DeclRange:    ej.DeclRange,
+		}
+	}
+
+	for key, msj := range mj.ModuleSources {
+		deps, err := traversalsFromJSON(msj.DependsOn)
+		if err != nil {
+			return err
+		}
+		ms := &ModuleSource{
+			Name:      msj.Name,
+			Source:    msj.Source,
+			Version:   msj.Version,
+			DependsOn: deps,
+			DeclRange: msj.DeclRange,
+		}
+		if len(msj.Providers) > 0 {
+			ms.Providers = make(map[string]ProviderRef, len(msj.Providers))
+			for childRef, ref := range msj.Providers {
+				ms.Providers[childRef] = providerRefFromJSON(ref)
+			}
+		}
+		meta.ModuleSources[key] = ms
+	}
+
+	for key, vj := range mj.Variables {
+		v, err := variableFromJSON(vj)
+		if err != nil {
+			return fmt.Errorf("variable %q: %w", vj.Name, err)
+		}
+		meta.Variables[key] = v
+	}
+
+	for key, oj := range mj.Outputs {
+		deps, err := traversalsFromJSON(oj.DependsOn)
+		if err != nil {
+			return err
+		}
+		meta.Outputs[key] = &Output{
+			Name:        oj.Name,
+			Description: oj.Description,
+			IsSensitive: oj.IsSensitive,
+			Ephemeral:   oj.Ephemeral,
+			Value:       exprFromRange(oj.ValueRange),
+			DependsOn:   deps,
+			DeclRange:   oj.DeclRange,
+		}
+	}
+
+	*m = meta
+	return nil
+}
+
+func providerRefToJSON(ref ProviderRef) providerRefJSON {
+	return providerRefJSON{
+		LocalName: ref.LocalName,
+		Alias:     ref.Alias,
+	}
+}
+
+func providerRefFromJSON(ref providerRefJSON) ProviderRef {
+	return ProviderRef{
+		LocalName: ref.LocalName,
+		Alias:     ref.Alias,
+	}
+}
+
+func providerToJSON(pAddr tfaddr.Provider) string {
+	if pAddr.IsZero() {
+		return ""
+	}
+	return pAddr.String()
+}
+
+func providerFromJSON(raw string) (tfaddr.Provider, error) {
+	if raw == "" {
+		return tfaddr.Provider{}, nil
+	}
+	pAddr, err := tfaddr.ParseRawProviderSourceString(raw)
+	if err != nil {
+		return tfaddr.Provider{}, fmt.Errorf("invalid provider address %q: %w", raw, err)
+	}
+	return pAddr, nil
+}
+
+func constraintsToJSON(constraints version.Constraints) []string {
+	if constraints == nil {
+		return nil
+	}
+	raw := make([]string, len(constraints))
+	for i, c := range constraints {
+		raw[i] = c.String()
+	}
+	return raw
+}
+
+func constraintsFromJSON(raw []string) (version.Constraints, error) {
+	constraints := make(version.Constraints, 0, len(raw))
+	for _, rc := range raw {
+		c, err := version.NewConstraint(rc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %w", rc, err)
+		}
+		constraints = append(constraints, c...)
+	}
+	return constraints, nil
+}
+
+func exprRange(expr hcl.Expression) *hcl.Range {
+	if expr == nil {
+		return nil
+	}
+	return expr.Range().Ptr()
+}
+
+// exprFromRange returns a placeholder of the expression
+// serialized as the given range, or nil if there is none
+func exprFromRange(rng *hcl.Range) hcl.Expression {
+	if rng == nil {
+		return nil
+	}
+	return hcl.StaticExpr(cty.DynamicVal, *rng)
+}
+
+func provisionersToJSON(provisioners []*Provisioner) []provisionerJSON {
+	if len(provisioners) == 0 {
+		return nil
+	}
+	pj := make([]provisionerJSON, len(provisioners))
+	for i, p := range provisioners {
+		pj[i] = provisionerJSON{
+			Type:          p.Type,
+			WhenDestroy:   p.WhenDestroy,
+			HasConnection: p.HasConnection,
+			DeclRange:     p.DeclRange,
+		}
+	}
+	return pj
+}
+
+func provisionersFromJSON(pj []provisionerJSON) []*Provisioner {
+	if len(pj) == 0 {
+		return nil
+	}
+	provisioners := make([]*Provisioner, len(pj))
+	for i, p := range pj {
+		provisioners[i] = &Provisioner{
+			Type:          p.Type,
+			WhenDestroy:   p.WhenDestroy,
+			HasConnection: p.HasConnection,
+			DeclRange:     p.DeclRange,
+		}
+	}
+	return provisioners
+}
+
+func checkRulesToJSON(rules []*CheckRule) []checkRuleJSON {
+	if len(rules) == 0 {
+		return nil
+	}
+	rj := make([]checkRuleJSON, len(rules))
+	for i, rule := range rules {
+		rj[i] = checkRuleJSON{
+			ConditionRange:    exprRange(rule.Condition),
+			ErrorMessageRange: exprRange(rule.ErrorMessage),
+		}
+	}
+	return rj
+}
+
+func checkRulesFromJSON(rj []checkRuleJSON) []*CheckRule {
+	if len(rj) == 0 {
+		return nil
+	}
+	rules := make([]*CheckRule, len(rj))
+	for i, r := range rj {
+		rules[i] = &CheckRule{
+			Condition:    exprFromRange(r.ConditionRange),
+			ErrorMessage: exprFromRange(r.ErrorMessageRange),
+		}
+	}
+	return rules
+}
+
+func traversalsToJSON(traversals []hcl.Traversal) []traversalJSON {
+	if len(traversals) == 0 {
+		return nil
+	}
+	tj := make([]traversalJSON, len(traversals))
+	for i, traversal := range traversals {
+		tj[i] = traversalJSON{
+			Traversal: traversalSource(traversal),
+			Range:     traversal.SourceRange(),
+		}
+	}
+	return tj
+}
+
+func traversalsFromJSON(tj []traversalJSON) ([]hcl.Traversal, error) {
+	if len(tj) == 0 {
+		return nil, nil
+	}
+	traversals := make([]hcl.Traversal, len(tj))
+	for i, t := range tj {
+		traversal, diags := hclsyntax.ParseTraversalAbs([]byte(t.Traversal), t.Range.Filename, t.Range.Start)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid traversal %q: %s", t.Traversal, diags)
+		}
+		traversals[i] = traversal
+	}
+	return traversals, nil
+}
+
+// traversalSource renders the given absolute traversal
+// back into its (normalized) source form
+func traversalSource(traversal hcl.Traversal) string {
+	var src string
+	for _, step := range traversal {
+		switch ts := step.(type) {
+		case hcl.TraverseRoot:
+			src += ts.Name
+		case hcl.TraverseAttr:
+			src += "." + ts.Name
+		case hcl.TraverseIndex:
+			switch {
+			case ts.Key.Type() == cty.String:
+				src += "[" + strconv.Quote(ts.Key.AsString()) + "]"
+			case ts.Key.Type() == cty.Number:
+				src += "[" + ts.Key.AsBigFloat().Text('f', -1) + "]"
+			}
+		case hcl.TraverseSplat:
+			src += "[*]"
+		}
+	}
+	return src
+}
+
+func variableToJSON(v *Variable) (*variableJSON, error) {
+	vj := &variableJSON{
+		Name:        v.Name,
+		Description: v.Description,
+		IsSensitive: v.IsSensitive,
+		IsNullable:  v.IsNullable,
+		Ephemeral:   v.Ephemeral,
+		Validations: checkRulesToJSON(v.Validations),
+		DeclRange:   v.DeclRange,
+	}
+
+	if v.Type != cty.NilType {
+		typ, err := v.Type.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		vj.Type = typ
+	}
+
+	if v.DefaultValue.Type() != cty.NilType {
+		ty := v.DefaultValue.Type()
+		typ, err := ty.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		val, err := ctyjson.Marshal(v.DefaultValue, ty)
+		if err != nil {
+			return nil, err
+		}
+		vj.DefaultType = typ
+		vj.Default = val
+	}
+
+	return vj, nil
+}
+
+func variableFromJSON(vj *variableJSON) (*Variable, error) {
+	v := &Variable{
+		Name:        vj.Name,
+		Description: vj.Description,
+		IsSensitive: vj.IsSensitive,
+		IsNullable:  vj.IsNullable,
+		Ephemeral:   vj.Ephemeral,
+		Validations: checkRulesFromJSON(vj.Validations),
+		DeclRange:   vj.DeclRange,
+	}
+
+	if len(vj.Type) > 0 {
+		err := v.Type.UnmarshalJSON(vj.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(vj.DefaultType) > 0 {
+		var ty cty.Type
+		err := ty.UnmarshalJSON(vj.DefaultType)
+		if err != nil {
+			return nil, err
+		}
+		val, err := ctyjson.Unmarshal(vj.Default, ty)
+		if err != nil {
+			return nil, err
+		}
+		v.DefaultValue = val
+	}
+
+	return v, nil
+}
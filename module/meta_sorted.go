@@ -0,0 +1,77 @@
+package module
+
+import (
+	"sort"
+)
+
+// SortedResources returns resources sorted by their map keys
+func (m *Meta) SortedResources() []*Resource {
+	resources := make([]*Resource, 0, len(m.Resources))
+	for _, r := range m.Resources {
+		resources = append(resources, r)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].MapKey() < resources[j].MapKey()
+	})
+	return resources
+}
+
+// SortedDataSources returns data sources sorted by their map keys
+func (m *Meta) SortedDataSources() []*DataSource {
+	dataSources := make([]*DataSource, 0, len(m.DataSources))
+	for _, ds := range m.DataSources {
+		dataSources = append(dataSources, ds)
+	}
+	sort.Slice(dataSources, func(i, j int) bool {
+		return dataSources[i].MapKey() < dataSources[j].MapKey()
+	})
+	return dataSources
+}
+
+// SortedEphemeralResources returns ephemeral resources sorted by their map keys
+func (m *Meta) SortedEphemeralResources() []*EphemeralResource {
+	ephemeralResources := make([]*EphemeralResource, 0, len(m.EphemeralResources))
+	for _, er := range m.EphemeralResources {
+		ephemeralResources = append(ephemeralResources, er)
+	}
+	sort.Slice(ephemeralResources, func(i, j int) bool {
+		return ephemeralResources[i].MapKey() < ephemeralResources[j].MapKey()
+	})
+	return ephemeralResources
+}
+
+// SortedModuleSources returns module sources sorted by their map keys
+func (m *Meta) SortedModuleSources() []*ModuleSource {
+	moduleSources := make([]*ModuleSource, 0, len(m.ModuleSources))
+	for _, ms := range m.ModuleSources {
+		moduleSources = append(moduleSources, ms)
+	}
+	sort.Slice(moduleSources, func(i, j int) bool {
+		return moduleSources[i].MapKey() < moduleSources[j].MapKey()
+	})
+	return moduleSources
+}
+
+// SortedVariables returns variables sorted by their map keys
+func (m *Meta) SortedVariables() []*Variable {
+	variables := make([]*Variable, 0, len(m.Variables))
+	for _, v := range m.Variables {
+		variables = append(variables, v)
+	}
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].MapKey() < variables[j].MapKey()
+	})
+	return variables
+}
+
+// SortedOutputs returns outputs sorted by their map keys
+func (m *Meta) SortedOutputs() []*Output {
+	outputs := make([]*Output, 0, len(m.Outputs))
+	for _, o := range m.Outputs {
+		outputs = append(outputs, o)
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].MapKey() < outputs[j].MapKey()
+	})
+	return outputs
+}
@@ -0,0 +1,84 @@
+package module
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMeta_sortedAccessors(t *testing.T) {
+	meta := &Meta{
+		Resources:     make(map[string]*Resource, 0),
+		DataSources:   make(map[string]*DataSource, 0),
+		ModuleSources: make(map[string]*ModuleSource, 0),
+		Variables:     make(map[string]*Variable, 0),
+		Outputs:       make(map[string]*Output, 0),
+	}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("n%02d", 19-i)
+
+		r := &Resource{Type: "aws_instance", Name: name}
+		meta.Resources[r.MapKey()] = r
+		ds := &DataSource{Type: "aws_ami", Name: name}
+		meta.DataSources[ds.MapKey()] = ds
+		ms := &ModuleSource{Name: name}
+		meta.ModuleSources[ms.MapKey()] = ms
+		v := &Variable{Name: name}
+		meta.Variables[v.MapKey()] = v
+		o := &Output{Name: name}
+		meta.Outputs[o.MapKey()] = o
+	}
+
+	expectedKeys := func(prefix string) []string {
+		keys := make([]string, 0, 20)
+		for i := 0; i < 20; i++ {
+			keys = append(keys, fmt.Sprintf("%sn%02d", prefix, i))
+		}
+		return keys
+	}
+
+	// map iteration order is randomized, so repeated calls
+	// would reveal any dependency on it
+	for i := 0; i < 5; i++ {
+		keys := make([]string, 0)
+		for _, r := range meta.SortedResources() {
+			keys = append(keys, r.MapKey())
+		}
+		if diff := cmp.Diff(expectedKeys("aws_instance."), keys); diff != "" {
+			t.Fatalf("unexpected resources: %s", diff)
+		}
+
+		keys = make([]string, 0)
+		for _, ds := range meta.SortedDataSources() {
+			keys = append(keys, ds.MapKey())
+		}
+		if diff := cmp.Diff(expectedKeys("data.aws_ami."), keys); diff != "" {
+			t.Fatalf("unexpected data sources: %s", diff)
+		}
+
+		keys = make([]string, 0)
+		for _, ms := range meta.SortedModuleSources() {
+			keys = append(keys, ms.MapKey())
+		}
+		if diff := cmp.Diff(expectedKeys("module."), keys); diff != "" {
+			t.Fatalf("unexpected module sources: %s", diff)
+		}
+
+		keys = make([]string, 0)
+		for _, v := range meta.SortedVariables() {
+			keys = append(keys, v.MapKey())
+		}
+		if diff := cmp.Diff(expectedKeys("var."), keys); diff != "" {
+			t.Fatalf("unexpected variables: %s", diff)
+		}
+
+		keys = make([]string, 0)
+		for _, o := range meta.SortedOutputs() {
+			keys = append(keys, o.MapKey())
+		}
+		if diff := cmp.Diff(expectedKeys("output."), keys); diff != "" {
+			t.Fatalf("unexpected outputs: %s", diff)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProviderRef_String(t *testing.T) {
+	testCases := []struct {
+		ref      ProviderRef
+		expected string
+	}{
+		{ProviderRef{LocalName: "aws"}, "aws"},
+		{ProviderRef{LocalName: "aws", Alias: "west"}, "aws.west"},
+		{ProviderRef{LocalName: "google-beta", Alias: "eu_1"}, "google-beta.eu_1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			if given := tc.ref.String(); given != tc.expected {
+				t.Fatalf("expected %q, given %q", tc.expected, given)
+			}
+
+			ref, err := ParseProviderRef(tc.ref.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ref, ref); diff != "" {
+				t.Fatalf("round-tripped reference doesn't match: %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseProviderRef_invalid(t *testing.T) {
+	testCases := []string{
+		"",
+		"aws.",
+		".west",
+		"aws.west.extra",
+		"aws[0]",
+		"0aws",
+	}
+
+	for _, raw := range testCases {
+		t.Run(raw, func(t *testing.T) {
+			if ref, err := ParseProviderRef(raw); err == nil {
+				t.Fatalf("expected %q to fail parsing, given: %#v", raw, ref)
+			}
+		})
+	}
+}
+
+func TestMeta_RequiredCoreConstraints(t *testing.T) {
+	meta := &Meta{}
+	constraints, diags := meta.RequiredCoreConstraints()
+	if constraints != nil || diags != nil {
+		t.Fatalf("expected no constraints and no diagnostics, given %q, %v", constraints, diags)
+	}
+
+	meta = &Meta{
+		RequiredCore: []string{">= 1.0, < 2.0", "~> 1.5"},
+	}
+	constraints, diags = meta.RequiredCoreConstraints()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if given, expected := constraints.String(), ">= 1.0, < 2.0,~> 1.5"; given != expected {
+		t.Fatalf("expected %q, given %q", expected, given)
+	}
+}
+
+func TestMeta_RequiredCoreConstraints_invalid(t *testing.T) {
+	meta := &Meta{
+		RequiredCore: []string{">= 1.0", "not-a-version", "< 2.0"},
+	}
+	constraints, diags := meta.RequiredCoreConstraints()
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly one diagnostic, given: %v", diags)
+	}
+	if diags[0].Summary != "Unable to parse terraform requirements" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+	if given, expected := constraints.String(), ">= 1.0,< 2.0"; given != expected {
+		t.Fatalf("expected %q, given %q", expected, given)
+	}
+}
+
+func TestParseCoreConstraints(t *testing.T) {
+	constraints, diags := ParseCoreConstraints([]string{"~> 1.5"})
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	if given, expected := constraints.String(), "~> 1.5"; given != expected {
+		t.Fatalf("expected %q, given %q", expected, given)
+	}
+}
+
+func TestMeta_UsesProvider(t *testing.T) {
+	meta := &Meta{
+		RequiredProviders: map[string]*ProviderRequirement{
+			"random": {Source: "hashicorp/random"},
+		},
+		ProviderConfigs: []*ProviderConfig{
+			{LocalName: "google"},
+		},
+		Resources: map[string]*Resource{
+			"aws_instance.web": {
+				Type:     "aws_instance",
+				Name:     "web",
+				Provider: ProviderRef{LocalName: "aws", Alias: "west"},
+			},
+		},
+	}
+
+	testCases := map[string]bool{
+		"random":   true,
+		"google":   true,
+		"aws":      true,
+		"aws.west": true,
+		"aws.east": true,
+		"azurerm":  false,
+	}
+	for localName, expected := range testCases {
+		if given := meta.UsesProvider(localName); given != expected {
+			t.Errorf("%s: expected %t, given %t", localName, expected, given)
+		}
+	}
+}
+
+func TestMeta_UsesProvider_requiredOnly(t *testing.T) {
+	meta := &Meta{
+		RequiredProviders: map[string]*ProviderRequirement{
+			"aws": {Source: "hashicorp/aws", VersionConstraints: []string{"~> 5.0"}},
+		},
+	}
+
+	if !meta.UsesProvider("aws") {
+		t.Fatal("expected provider declared in required_providers to be used")
+	}
+	if meta.UsesProvider("google") {
+		t.Fatal("expected undeclared provider not to be used")
+	}
+}
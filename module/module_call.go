@@ -0,0 +1,37 @@
+package module
+
+import (
+	"sort"
+)
+
+// ModuleCall represents a call to a child module
+// with its source already classified
+type ModuleCall struct {
+	Name    string
+	Source  string
+	Version string
+	Kind    ModuleSourceKind
+
+	// Providers maps provider references in the child module
+	// to provider configurations in the calling module
+	Providers map[string]ProviderRef
+}
+
+// ModuleCalls returns calls to child modules sorted by name
+func (m *Meta) ModuleCalls() []ModuleCall {
+	calls := make([]ModuleCall, 0, len(m.ModuleSources))
+	for _, ms := range m.ModuleSources {
+		calls = append(calls, ModuleCall{
+			Name:      ms.Name,
+			Source:    ms.Source,
+			Version:   ms.Version,
+			Kind:      ms.Kind(),
+			Providers: ms.Providers,
+		})
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].Name < calls[j].Name
+	})
+
+	return calls
+}
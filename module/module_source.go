@@ -0,0 +1,356 @@
+package module
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+type ModuleSource struct {
+	Name   string
+	Source string
+
+	// Version represents the raw version constraint
+	// of registry modules, as declared
+	Version string
+
+	DependsOn []hcl.Traversal
+
+	// Providers maps provider references in the child module
+	// (e.g. "aws" or "aws.alt") to provider configurations
+	// in the calling module
+	Providers map[string]ProviderRef
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// MapKey returns a string that can be used to uniquely identify the receiver
+// in a map[string]*ModuleSource.
+func (ms *ModuleSource) MapKey() string {
+	return fmt.Sprintf("module.%s", ms.Name)
+}
+
+// Kind returns the kind of the source, i.e. whether it is
+// a local path, a Terraform Registry address or a remote source
+func (ms *ModuleSource) Kind() ModuleSourceKind {
+	return ms.ParsedSource().Kind()
+}
+
+// ParsedSource returns the source parsed into a typed struct
+// corresponding to its kind.
+func (ms *ModuleSource) ParsedSource() ParsedModuleSource {
+	return ParseModuleSource(ms.Source)
+}
+
+// ResolveLocal returns the path of a local module source resolved
+// relative to the given directory of the calling module, and true.
+// The path is cleaned, so it may climb above baseDir if the source
+// does, e.g. "../../modules/vpc" called from "env" yields "../modules/vpc".
+//
+// Non-local sources return an empty string and false.
+func (ms *ModuleSource) ResolveLocal(baseDir string) (string, bool) {
+	if ms.Kind() != LocalModuleSourceKind {
+		return "", false
+	}
+	return filepath.Clean(filepath.Join(baseDir, filepath.FromSlash(ms.Source))), true
+}
+
+type ModuleSourceKind int
+
+const (
+	UnknownModuleSourceKind ModuleSourceKind = iota
+	LocalModuleSourceKind
+	RegistryModuleSourceKind
+	RemoteModuleSourceKind
+)
+
+func (k ModuleSourceKind) String() string {
+	switch k {
+	case LocalModuleSourceKind:
+		return "local"
+	case RegistryModuleSourceKind:
+		return "registry"
+	case RemoteModuleSourceKind:
+		return "remote"
+	}
+	return "unknown"
+}
+
+// ParsedModuleSource represents a module source parsed
+// according to its kind
+type ParsedModuleSource interface {
+	Kind() ModuleSourceKind
+	String() string
+}
+
+// ParseModuleSource classifies the given raw source using the same
+// heuristics as Terraform, i.e. local paths start with ./ or ../,
+// registry addresses have the [host/]namespace/name/provider shape
+// and anything else is considered remote (git, https, s3 etc.)
+func ParseModuleSource(raw string) ParsedModuleSource {
+	if isLocalSourceAddr(raw) {
+		return LocalModuleSource{Path: raw}
+	}
+
+	if src, err := ParseRegistryModuleSource(raw); err == nil {
+		return src
+	}
+
+	if src, err := ParseRemoteModuleSource(raw); err == nil {
+		return src
+	}
+
+	return RemoteModuleSource{Raw: raw}
+}
+
+var localSourcePrefixes = []string{
+	"./",
+	"../",
+	".\\",
+	"..\\",
+}
+
+func isLocalSourceAddr(addr string) bool {
+	for _, prefix := range localSourcePrefixes {
+		if strings.HasPrefix(addr, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	registryHostSubRe     = `[0-9A-Za-z](?:[0-9A-Za-z-]*[0-9A-Za-z])?(?:\.[0-9A-Za-z](?:[0-9A-Za-z-]*[0-9A-Za-z])?)*(?::[0-9]+)?`
+	registryNameSubRe     = `[0-9A-Za-z](?:[0-9A-Za-z-_]{0,62}[0-9A-Za-z])?`
+	registryProviderSubRe = `[0-9a-z]{1,64}`
+)
+
+var (
+	registrySourceRe = regexp.MustCompile(fmt.Sprintf("^(?:(%s)/)?(%s)/(%s)/(%s)$",
+		registryHostSubRe, registryNameSubRe, registryNameSubRe, registryProviderSubRe))
+
+	// disallowedRegistryHostRe matches well-known hosts which are
+	// interpreted by go-getter and never as a module registry
+	disallowedRegistryHostRe = regexp.MustCompile(`^(github\.com|bitbucket\.org)$`)
+)
+
+// LocalModuleSource represents a local path to a module,
+// relative to the calling module
+type LocalModuleSource struct {
+	Path string
+}
+
+func (s LocalModuleSource) Kind() ModuleSourceKind {
+	return LocalModuleSourceKind
+}
+
+func (s LocalModuleSource) String() string {
+	return s.Path
+}
+
+// DefaultModuleRegistryHost is the host of the public
+// Terraform Registry, used when a source omits the host
+const DefaultModuleRegistryHost = "registry.terraform.io"
+
+// RegistryModuleSource represents a module address
+// in a Terraform Registry
+type RegistryModuleSource struct {
+	Raw string
+
+	Host         string
+	Namespace    string
+	Name         string
+	TargetSystem string
+
+	// Subdir is the optional subdirectory within the module package,
+	// such as modules/consul-cluster in
+	// hashicorp/consul/aws//modules/consul-cluster
+	Subdir string
+}
+
+// ParseRegistryModuleSource parses the given raw source in the
+// [host/]namespace/name/provider[//subdir] format, where the host is
+// registry.terraform.io if omitted
+func ParseRegistryModuleSource(raw string) (RegistryModuleSource, error) {
+	addr, subdir := splitSourceSubdir(raw)
+
+	matches := registrySourceRe.FindStringSubmatch(addr)
+	if matches == nil {
+		parts := strings.Split(addr, "/")
+		if len(parts) < 3 || len(parts) > 4 {
+			return RegistryModuleSource{}, fmt.Errorf("%q: registry source must have 3 or 4 segments, %d given",
+				raw, len(parts))
+		}
+		return RegistryModuleSource{}, fmt.Errorf("%q: invalid registry source, expected [host/]namespace/name/provider", raw)
+	}
+
+	host := matches[1]
+	if host == "" {
+		host = DefaultModuleRegistryHost
+	} else if disallowedRegistryHostRe.MatchString(host) {
+		return RegistryModuleSource{}, fmt.Errorf("%q: %q cannot be used as a module registry host", raw, host)
+	}
+
+	return RegistryModuleSource{
+		Raw:          raw,
+		Host:         strings.ToLower(host),
+		Namespace:    matches[2],
+		Name:         matches[3],
+		TargetSystem: matches[4],
+		Subdir:       subdir,
+	}, nil
+}
+
+func (s RegistryModuleSource) Kind() ModuleSourceKind {
+	return RegistryModuleSourceKind
+}
+
+func (s RegistryModuleSource) String() string {
+	return s.Raw
+}
+
+// RemoteModuleSource represents a module address to be
+// installed via go-getter, such as git, https or s3
+type RemoteModuleSource struct {
+	Raw string
+
+	// Getter is the forced getter, such as git in git::https://...
+	Getter string
+
+	// URL is the address without the forced getter,
+	// subdirectory and query parameters
+	URL string
+
+	Subdir string
+
+	Ref   string
+	Depth string
+
+	// Query contains all query parameters, including ref and depth
+	Query url.Values
+}
+
+var forcedGetterRe = regexp.MustCompile(`^([A-Za-z0-9]+)::(.+)$`)
+
+// ParseRemoteModuleSource parses the given raw source following
+// go-getter's conventions, i.e. getter::url//subdir?key=value
+func ParseRemoteModuleSource(raw string) (RemoteModuleSource, error) {
+	src := RemoteModuleSource{
+		Raw: raw,
+	}
+
+	addr := raw
+	if matches := forcedGetterRe.FindStringSubmatch(addr); matches != nil {
+		src.Getter = matches[1]
+		addr = matches[2]
+	}
+
+	addr, src.Subdir = splitSourceSubdir(addr)
+
+	if idx := strings.Index(addr, "?"); idx > -1 {
+		query, err := url.ParseQuery(addr[idx+1:])
+		if err != nil {
+			return RemoteModuleSource{}, fmt.Errorf("%q: invalid query parameters: %w", raw, err)
+		}
+		src.Query = query
+		src.Ref = query.Get("ref")
+		src.Depth = query.Get("depth")
+		addr = addr[:idx]
+	}
+
+	src.URL = addr
+
+	return src, nil
+}
+
+// splitSourceSubdir splits the subdirectory from the source address
+// in the same way as go-getter (SourceDirSubdir), moving any query
+// parameters of the subdirectory back onto the address
+func splitSourceSubdir(src string) (string, string) {
+	stop := len(src)
+	if idx := strings.Index(src, "?"); idx > -1 {
+		stop = idx
+	}
+
+	// Avoid accidentally marking the scheme as the subdir
+	var offset int
+	if idx := strings.Index(src[:stop], "://"); idx > -1 {
+		offset = idx + 3
+	}
+
+	idx := strings.Index(src[offset:stop], "//")
+	if idx == -1 {
+		return src, ""
+	}
+	idx += offset
+
+	subdir := src[idx+2:]
+	src = src[:idx]
+
+	if idx = strings.Index(subdir, "?"); idx > -1 {
+		src += subdir[idx:]
+		subdir = subdir[:idx]
+	}
+
+	return src, subdir
+}
+
+func (s RemoteModuleSource) Kind() ModuleSourceKind {
+	return RemoteModuleSourceKind
+}
+
+// gitHostingHosts are hosts whose shorthand sources (host/owner/repo)
+// go-getter detects as git repositories
+var gitHostingHosts = map[string]bool{
+	"github.com":    true,
+	"bitbucket.org": true,
+}
+
+// NormalizedURL returns the source with shorthands of well-known
+// git hosting services expanded in the same way as go-getter
+// detects them, e.g. github.com/org/repo becomes
+// git::https://github.com/org/repo.git
+//
+// Any other sources are returned unchanged.
+func (s RemoteModuleSource) NormalizedURL() string {
+	if s.Getter != "" {
+		return s.Raw
+	}
+
+	parts := strings.Split(s.URL, "/")
+	if len(parts) < 3 || !gitHostingHosts[parts[0]] {
+		return s.Raw
+	}
+
+	normalized := "git::https://" + strings.Join(parts[:3], "/")
+	if !strings.HasSuffix(normalized, ".git") {
+		normalized += ".git"
+	}
+
+	// Any path beyond the repository is a subdirectory
+	subdir := strings.Join(parts[3:], "/")
+	if s.Subdir != "" {
+		if subdir != "" {
+			subdir += "/"
+		}
+		subdir += s.Subdir
+	}
+	if subdir != "" {
+		normalized += "//" + subdir
+	}
+
+	// preserve the order of query parameters as declared
+	if idx := strings.Index(s.Raw, "?"); idx > -1 {
+		normalized += s.Raw[idx:]
+	}
+
+	return normalized
+}
+
+func (s RemoteModuleSource) String() string {
+	return s.Raw
+}
@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package module
+
+import (
+	"testing"
+)
+
+func FuzzParseModuleSource(f *testing.F) {
+	seeds := []string{
+		"",
+		".",
+		"./",
+		"../",
+		".\\modules\\vpc",
+		"./modules/vpc",
+		"hashicorp/consul/aws",
+		"hashicorp/consul/aws//modules/consul-cluster",
+		"app.terraform.io/foo/bar/aws",
+		"localhost:8080/foo/bar/aws",
+		"//",
+		"///",
+		"a/b/c/d/e",
+		"github.com/hashicorp/example",
+		"github.com/hashicorp/example/aws",
+		"bitbucket.org/hashicorp/terraform-consul-aws",
+		"git::https://example.com/vpc.git?ref=v1.2.0",
+		"git::ssh://git@example.com/vpc.git//modules/vpc?ref=v1.2.0",
+		"git@github.com:hashicorp/example.git",
+		"https://example.com/vpc-module.zip",
+		"s3::https://s3-eu-west-1.amazonaws.com/examplecorp-terraform-modules/vpc.zip",
+		"gcs::https://www.googleapis.com/storage/v1/modules/foomodule.zip",
+		"::",
+		"git::",
+		"hashicorp/consul/AWS",
+		"modules/vpc",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		parsed := ParseModuleSource(raw)
+
+		ms := &ModuleSource{Name: "fuzz", Source: raw}
+		// the concrete type of the parsed source must match its kind
+		var expectedKind ModuleSourceKind
+		switch parsed.(type) {
+		case LocalModuleSource:
+			expectedKind = LocalModuleSourceKind
+		case RegistryModuleSource:
+			expectedKind = RegistryModuleSourceKind
+		case RemoteModuleSource:
+			expectedKind = RemoteModuleSourceKind
+		default:
+			t.Fatalf("%q: unexpected parsed source type %T", raw, parsed)
+		}
+		if kind := ms.Kind(); kind != expectedKind {
+			t.Fatalf("%q: Kind() returned %s for %T", raw, kind, parsed)
+		}
+
+		// rendering the parsed source must not panic
+		_ = parsed.String()
+		if remote, ok := parsed.(RemoteModuleSource); ok {
+			_ = remote.NormalizedURL()
+		}
+		_, _ = ms.ResolveLocal("base")
+	})
+}
@@ -0,0 +1,312 @@
+package module
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleSource_Kind(t *testing.T) {
+	testCases := []struct {
+		source       string
+		expectedKind ModuleSourceKind
+	}{
+		{"./modules/vpc", LocalModuleSourceKind},
+		{"../vpc", LocalModuleSourceKind},
+		{"hashicorp/consul/aws", RegistryModuleSourceKind},
+		{"app.terraform.io/foo/bar/aws", RegistryModuleSourceKind},
+		{"localhost:8080/foo/bar/aws", RegistryModuleSourceKind},
+		{"hashicorp/consul/aws//modules/consul-cluster", RegistryModuleSourceKind},
+		{"github.com/hashicorp/example", RemoteModuleSourceKind},
+		{"github.com/hashicorp/example/aws", RemoteModuleSourceKind},
+		{"bitbucket.org/hashicorp/terraform-consul-aws", RemoteModuleSourceKind},
+		{"git::https://example.com/vpc.git", RemoteModuleSourceKind},
+		{"https://example.com/vpc-module.zip", RemoteModuleSourceKind},
+		{"s3::https://s3-eu-west-1.amazonaws.com/examplecorp-terraform-modules/vpc.zip", RemoteModuleSourceKind},
+		{"hashicorp/consul/AWS", RemoteModuleSourceKind},
+		{"modules/vpc", RemoteModuleSourceKind},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.source), func(t *testing.T) {
+			ms := &ModuleSource{Name: "test", Source: tc.source}
+			kind := ms.Kind()
+			if kind != tc.expectedKind {
+				t.Fatalf("expected %s, given: %s", tc.expectedKind, kind)
+			}
+			parsed := ms.ParsedSource()
+			if parsed.Kind() != kind {
+				t.Fatalf("parsed source kind (%s) doesn't match %s", parsed.Kind(), kind)
+			}
+			if parsed.String() != tc.source {
+				t.Fatalf("expected parsed source to render as %q, given: %q", tc.source, parsed.String())
+			}
+		})
+	}
+}
+
+func TestModuleSource_ResolveLocal(t *testing.T) {
+	testCases := []struct {
+		baseDir       string
+		source        string
+		expectedPath  string
+		expectedLocal bool
+	}{
+		{"env/prod", "../../modules/vpc", filepath.Join("modules", "vpc"), true},
+		{"env/prod", "./modules/vpc", filepath.Join("env", "prod", "modules", "vpc"), true},
+		{"env/prod", "./modules/../vpc/", filepath.Join("env", "prod", "vpc"), true},
+		{"env", "../../shared/vpc", filepath.Join("..", "shared", "vpc"), true},
+		{"/srv/env/prod", "../../modules/vpc", filepath.Join("/srv", "modules", "vpc"), true},
+		{"env/prod", "hashicorp/consul/aws", "", false},
+		{"env/prod", "git::https://example.com/vpc.git", "", false},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.source), func(t *testing.T) {
+			ms := &ModuleSource{Name: "test", Source: tc.source}
+			path, local := ms.ResolveLocal(filepath.FromSlash(tc.baseDir))
+			if local != tc.expectedLocal {
+				t.Fatalf("expected local to be %t, given %t", tc.expectedLocal, local)
+			}
+			if path != filepath.FromSlash(tc.expectedPath) {
+				t.Fatalf("expected path %q, given %q", tc.expectedPath, path)
+			}
+		})
+	}
+}
+
+func TestParseRegistryModuleSource(t *testing.T) {
+	testCases := []struct {
+		source         string
+		expectedSource RegistryModuleSource
+		expectedErr    string
+	}{
+		{
+			"hashicorp/consul/aws",
+			RegistryModuleSource{
+				Raw:          "hashicorp/consul/aws",
+				Host:         "registry.terraform.io",
+				Namespace:    "hashicorp",
+				Name:         "consul",
+				TargetSystem: "aws",
+			},
+			"",
+		},
+		{
+			"app.terraform.io/example-corp/k8s-cluster/azurerm",
+			RegistryModuleSource{
+				Raw:          "app.terraform.io/example-corp/k8s-cluster/azurerm",
+				Host:         "app.terraform.io",
+				Namespace:    "example-corp",
+				Name:         "k8s-cluster",
+				TargetSystem: "azurerm",
+			},
+			"",
+		},
+		{
+			"Registry.Example.com:8443/infra/network/google",
+			RegistryModuleSource{
+				Raw:          "Registry.Example.com:8443/infra/network/google",
+				Host:         "registry.example.com:8443",
+				Namespace:    "infra",
+				Name:         "network",
+				TargetSystem: "google",
+			},
+			"",
+		},
+		{
+			"hashicorp/consul/aws//modules/consul-cluster",
+			RegistryModuleSource{
+				Raw:          "hashicorp/consul/aws//modules/consul-cluster",
+				Host:         "registry.terraform.io",
+				Namespace:    "hashicorp",
+				Name:         "consul",
+				TargetSystem: "aws",
+				Subdir:       "modules/consul-cluster",
+			},
+			"",
+		},
+		{
+			"app.terraform.io/example-corp/k8s-cluster/azurerm//modules/node-pool",
+			RegistryModuleSource{
+				Raw:          "app.terraform.io/example-corp/k8s-cluster/azurerm//modules/node-pool",
+				Host:         "app.terraform.io",
+				Namespace:    "example-corp",
+				Name:         "k8s-cluster",
+				TargetSystem: "azurerm",
+				Subdir:       "modules/node-pool",
+			},
+			"",
+		},
+		{
+			"hashicorp/consul//modules/consul-cluster",
+			RegistryModuleSource{},
+			`"hashicorp/consul//modules/consul-cluster": registry source must have 3 or 4 segments, 2 given`,
+		},
+		{
+			"hashicorp/consul",
+			RegistryModuleSource{},
+			`"hashicorp/consul": registry source must have 3 or 4 segments, 2 given`,
+		},
+		{
+			"a/b/c/d/e",
+			RegistryModuleSource{},
+			`"a/b/c/d/e": registry source must have 3 or 4 segments, 5 given`,
+		},
+		{
+			"hashicorp/con$ul/aws",
+			RegistryModuleSource{},
+			`"hashicorp/con$ul/aws": invalid registry source, expected [host/]namespace/name/provider`,
+		},
+		{
+			"github.com/hashicorp/consul/aws",
+			RegistryModuleSource{},
+			`"github.com/hashicorp/consul/aws": "github.com" cannot be used as a module registry host`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.source), func(t *testing.T) {
+			src, err := ParseRegistryModuleSource(tc.source)
+			if err != nil {
+				if tc.expectedErr == "" {
+					t.Fatal(err)
+				}
+				if err.Error() != tc.expectedErr {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			} else if tc.expectedErr != "" {
+				t.Fatalf("expected error: %s", tc.expectedErr)
+			}
+
+			if src != tc.expectedSource {
+				t.Fatalf("source mismatch.\nexpected: %#v\ngiven: %#v", tc.expectedSource, src)
+			}
+		})
+	}
+}
+
+func TestParseRemoteModuleSource(t *testing.T) {
+	testCases := []struct {
+		source         string
+		expectedSource RemoteModuleSource
+	}{
+		{
+			"github.com/hashicorp/example",
+			RemoteModuleSource{
+				Raw: "github.com/hashicorp/example",
+				URL: "github.com/hashicorp/example",
+			},
+		},
+		{
+			"github.com/hashicorp/example//modules/vpc?ref=v1.2.0",
+			RemoteModuleSource{
+				Raw:    "github.com/hashicorp/example//modules/vpc?ref=v1.2.0",
+				URL:    "github.com/hashicorp/example",
+				Subdir: "modules/vpc",
+				Ref:    "v1.2.0",
+				Query:  url.Values{"ref": []string{"v1.2.0"}},
+			},
+		},
+		{
+			"bitbucket.org/hashicorp/terraform-consul-aws",
+			RemoteModuleSource{
+				Raw: "bitbucket.org/hashicorp/terraform-consul-aws",
+				URL: "bitbucket.org/hashicorp/terraform-consul-aws",
+			},
+		},
+		{
+			"git::https://example.com/repo.git//subdir?ref=v1.2.0&depth=1",
+			RemoteModuleSource{
+				Raw:    "git::https://example.com/repo.git//subdir?ref=v1.2.0&depth=1",
+				Getter: "git",
+				URL:    "https://example.com/repo.git",
+				Subdir: "subdir",
+				Ref:    "v1.2.0",
+				Depth:  "1",
+				Query: url.Values{
+					"ref":   []string{"v1.2.0"},
+					"depth": []string{"1"},
+				},
+			},
+		},
+		{
+			"s3::https://s3-eu-west-1.amazonaws.com/examplecorp-terraform-modules/vpc.zip//modules/subnet",
+			RemoteModuleSource{
+				Raw:    "s3::https://s3-eu-west-1.amazonaws.com/examplecorp-terraform-modules/vpc.zip//modules/subnet",
+				Getter: "s3",
+				URL:    "https://s3-eu-west-1.amazonaws.com/examplecorp-terraform-modules/vpc.zip",
+				Subdir: "modules/subnet",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.source), func(t *testing.T) {
+			src, err := ParseRemoteModuleSource(tc.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.expectedSource, src); diff != "" {
+				t.Fatalf("source mismatch: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRemoteModuleSource_NormalizedURL(t *testing.T) {
+	testCases := []struct {
+		source      string
+		expectedURL string
+	}{
+		{
+			"github.com/hashicorp/example",
+			"git::https://github.com/hashicorp/example.git",
+		},
+		{
+			"github.com/hashicorp/example//modules/vpc?ref=v1.2.0",
+			"git::https://github.com/hashicorp/example.git//modules/vpc?ref=v1.2.0",
+		},
+		{
+			"github.com/hashicorp/example.git?ref=main&depth=1",
+			"git::https://github.com/hashicorp/example.git?ref=main&depth=1",
+		},
+		{
+			"github.com/hashicorp/example/modules/vpc",
+			"git::https://github.com/hashicorp/example.git//modules/vpc",
+		},
+		{
+			"bitbucket.org/hashicorp/terraform-consul-aws",
+			"git::https://bitbucket.org/hashicorp/terraform-consul-aws.git",
+		},
+		{
+			"git::https://example.com/repo.git//subdir?ref=v1.2.0",
+			"git::https://example.com/repo.git//subdir?ref=v1.2.0",
+		},
+		{
+			"https://example.com/vpc-module.zip",
+			"https://example.com/vpc-module.zip",
+		},
+		{
+			"gitlab.com/hashicorp/example",
+			"gitlab.com/hashicorp/example",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.source), func(t *testing.T) {
+			src, err := ParseRemoteModuleSource(tc.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if normalized := src.NormalizedURL(); normalized != tc.expectedURL {
+				t.Fatalf("expected %q, given: %q", tc.expectedURL, normalized)
+			}
+		})
+	}
+}
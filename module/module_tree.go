@@ -0,0 +1,20 @@
+package module
+
+// ModuleTree represents a module along with
+// the local modules it calls, recursively
+type ModuleTree struct {
+	// Path is the cleaned path of the module directory
+	Path string
+
+	Meta *Meta
+
+	// Children contains trees of child modules keyed by the names
+	// of module calls. Calls of modules which couldn't be loaded
+	// or which point into ignored directories are not included.
+	Children map[string]*ModuleTree
+
+	// Unresolved contains calls of registry and remote modules
+	// which weren't loaded because they couldn't be resolved
+	// to a local directory, keyed by the names of module calls
+	Unresolved map[string]*ModuleSource
+}
@@ -0,0 +1,151 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type MoveEndpointKind int
+
+const (
+	UnknownMoveEndpointKind MoveEndpointKind = iota
+	ResourceMoveEndpointKind
+	ResourceInstanceMoveEndpointKind
+	ModuleCallMoveEndpointKind
+	ModuleInstanceMoveEndpointKind
+)
+
+func (k MoveEndpointKind) String() string {
+	switch k {
+	case ResourceMoveEndpointKind:
+		return "resource"
+	case ResourceInstanceMoveEndpointKind:
+		return "resource instance"
+	case ModuleCallMoveEndpointKind:
+		return "module call"
+	case ModuleInstanceMoveEndpointKind:
+		return "module instance"
+	}
+	return "unknown"
+}
+
+// MoveEndpoint represents the from or to address of a moved block
+type MoveEndpoint struct {
+	Kind MoveEndpointKind
+
+	// Module contains module calls leading to the endpoint,
+	// including the module call itself for module endpoints
+	Module []MoveEndpointModuleStep
+
+	// Type and Name identify the resource for resource endpoints
+	Type string
+	Name string
+
+	// Key is the instance key of resource instance endpoints,
+	// or cty.NilVal if there is none
+	Key cty.Value
+}
+
+// MoveEndpointModuleStep represents a single module call
+// within the address of a moved block endpoint
+type MoveEndpointModuleStep struct {
+	Name string
+
+	// Key is the instance key, or cty.NilVal if there is none
+	Key cty.Value
+}
+
+// IsModule returns true if the endpoint refers to a module call
+// or its instance rather than to a resource
+func (e *MoveEndpoint) IsModule() bool {
+	return e.Kind == ModuleCallMoveEndpointKind || e.Kind == ModuleInstanceMoveEndpointKind
+}
+
+// ParseMoveEndpoint classifies the given traversal (e.g. module.a[0].aws_instance.b)
+// as a resource, resource instance, module call or module instance address
+// in the same way Terraform parses moved block endpoints.
+func ParseMoveEndpoint(traversal hcl.Traversal) (*MoveEndpoint, hcl.Diagnostics) {
+	ep := &MoveEndpoint{
+		Module: make([]MoveEndpointModuleStep, 0),
+	}
+
+	remaining := traversal
+	for len(remaining) > 0 && stepName(remaining[0]) == "module" {
+		if len(remaining) < 2 || stepName(remaining[1]) == "" {
+			return nil, invalidMoveEndpoint(traversal, "Module calls must be followed by a module name, like module.foo.")
+		}
+
+		step := MoveEndpointModuleStep{
+			Name: stepName(remaining[1]),
+		}
+		remaining = remaining[2:]
+
+		ep.Kind = ModuleCallMoveEndpointKind
+		if len(remaining) > 0 {
+			if idx, ok := remaining[0].(hcl.TraverseIndex); ok {
+				step.Key = idx.Key
+				ep.Kind = ModuleInstanceMoveEndpointKind
+				remaining = remaining[1:]
+			}
+		}
+
+		ep.Module = append(ep.Module, step)
+	}
+
+	if len(remaining) == 0 {
+		if ep.Kind == UnknownMoveEndpointKind {
+			return nil, invalidMoveEndpoint(traversal, "The address must refer to a resource or a module call.")
+		}
+		return ep, nil
+	}
+
+	if stepName(remaining[0]) == "data" {
+		return nil, invalidMoveEndpoint(traversal, "Data sources cannot be moved, only managed resources.")
+	}
+
+	if len(remaining) < 2 || stepName(remaining[0]) == "" || stepName(remaining[1]) == "" {
+		return nil, invalidMoveEndpoint(traversal, "Resource addresses must consist of a type and a name, like aws_instance.foo.")
+	}
+	ep.Kind = ResourceMoveEndpointKind
+	ep.Type, ep.Name = stepName(remaining[0]), stepName(remaining[1])
+	remaining = remaining[2:]
+
+	if len(remaining) > 0 {
+		idx, ok := remaining[0].(hcl.TraverseIndex)
+		if !ok {
+			return nil, invalidMoveEndpoint(traversal, "Resource addresses may only be followed by an instance key, like aws_instance.foo[0].")
+		}
+		ep.Kind = ResourceInstanceMoveEndpointKind
+		ep.Key = idx.Key
+		remaining = remaining[1:]
+	}
+
+	if len(remaining) > 0 {
+		return nil, invalidMoveEndpoint(traversal, "Unexpected extra operators after the resource address.")
+	}
+
+	return ep, nil
+}
+
+// stepName returns the name of the given root or attribute step,
+// or an empty string for any other steps
+func stepName(step hcl.Traverser) string {
+	switch ts := step.(type) {
+	case hcl.TraverseRoot:
+		return ts.Name
+	case hcl.TraverseAttr:
+		return ts.Name
+	}
+	return ""
+}
+
+func invalidMoveEndpoint(traversal hcl.Traversal, detail string) hcl.Diagnostics {
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid move endpoint",
+			Detail:   detail,
+			Subject:  traversal.SourceRange().Ptr(),
+		},
+	}
+}
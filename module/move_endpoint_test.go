@@ -0,0 +1,123 @@
+package module
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty-debug/ctydebug"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParseMoveEndpoint(t *testing.T) {
+	testCases := []struct {
+		address     string
+		expected    *MoveEndpoint
+		expectedErr string
+	}{
+		{
+			"aws_instance.web",
+			&MoveEndpoint{
+				Kind:   ResourceMoveEndpointKind,
+				Module: []MoveEndpointModuleStep{},
+				Type:   "aws_instance",
+				Name:   "web",
+			},
+			"",
+		},
+		{
+			`aws_instance.web["primary"]`,
+			&MoveEndpoint{
+				Kind:   ResourceInstanceMoveEndpointKind,
+				Module: []MoveEndpointModuleStep{},
+				Type:   "aws_instance",
+				Name:   "web",
+				Key:    cty.StringVal("primary"),
+			},
+			"",
+		},
+		{
+			"module.network",
+			&MoveEndpoint{
+				Kind: ModuleCallMoveEndpointKind,
+				Module: []MoveEndpointModuleStep{
+					{Name: "network"},
+				},
+			},
+			"",
+		},
+		{
+			"module.network[0]",
+			&MoveEndpoint{
+				Kind: ModuleInstanceMoveEndpointKind,
+				Module: []MoveEndpointModuleStep{
+					{Name: "network", Key: cty.NumberIntVal(0)},
+				},
+			},
+			"",
+		},
+		{
+			"module.network[0].module.subnets.aws_subnet.private[1]",
+			&MoveEndpoint{
+				Kind: ResourceInstanceMoveEndpointKind,
+				Module: []MoveEndpointModuleStep{
+					{Name: "network", Key: cty.NumberIntVal(0)},
+					{Name: "subnets"},
+				},
+				Type: "aws_subnet",
+				Name: "private",
+				Key:  cty.NumberIntVal(1),
+			},
+			"",
+		},
+		{
+			"data.aws_ami.ubuntu",
+			nil,
+			"Data sources cannot be moved, only managed resources.",
+		},
+		{
+			"aws_instance",
+			nil,
+			"Resource addresses must consist of a type and a name, like aws_instance.foo.",
+		},
+		{
+			"aws_instance.web.id",
+			nil,
+			"Resource addresses may only be followed by an instance key, like aws_instance.foo[0].",
+		},
+		{
+			"module",
+			nil,
+			"Module calls must be followed by a module name, like module.foo.",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.address), func(t *testing.T) {
+			traversal, diags := hclsyntax.ParseTraversalAbs([]byte(tc.address), "", hcl.InitialPos)
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			ep, diags := ParseMoveEndpoint(traversal)
+			if tc.expectedErr != "" {
+				if len(diags) != 1 {
+					t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+				}
+				if diags[0].Detail != tc.expectedErr {
+					t.Fatalf("unexpected diagnostic: %s", diags[0].Detail)
+				}
+				return
+			}
+			if len(diags) > 0 {
+				t.Fatal(diags)
+			}
+
+			if diff := cmp.Diff(tc.expected, ep, ctydebug.CmpOptions); diff != "" {
+				t.Fatalf("endpoint mismatch: %s", diff)
+			}
+		})
+	}
+}
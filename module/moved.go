@@ -0,0 +1,12 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Moved represents a moved block, which records
+// that an object has moved to a new address
+type Moved struct {
+	From hcl.Traversal
+	To   hcl.Traversal
+}
@@ -0,0 +1,31 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+type Output struct {
+	Name        string
+	Description string
+	IsSensitive bool
+
+	// Ephemeral is true if the output was declared with
+	// ephemeral = true, i.e. its value is not persisted
+	Ephemeral bool
+
+	// Value is kept as an expression, so that references
+	// can be analyzed by the caller
+	Value hcl.Expression
+
+	DependsOn []hcl.Traversal
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// MapKey returns a string that can be used to uniquely identify the receiver
+// in a map[string]*Output.
+func (o *Output) MapKey() string {
+	return fmt.Sprintf("output.%s", o.Name)
+}
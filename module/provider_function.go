@@ -0,0 +1,58 @@
+package module
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ProviderFunctionRef represents a call of a provider-defined
+// function, such as provider::aws::arn_parse(...)
+type ProviderFunctionRef struct {
+	// LocalName is the local name of the provider
+	// the function belongs to, e.g. aws
+	LocalName string
+
+	// Function is the name of the function, e.g. arn_parse
+	Function string
+
+	// Range is the range of the whole function call
+	Range hcl.Range
+}
+
+// ProviderFunctionRefs returns calls of provider-defined functions
+// within the given expression, in order of appearance.
+//
+// Such calls are represented as function calls with a namespaced name
+// (provider::NAME::FUNC) in the native syntax. Other expressions,
+// such as JSON ones, yield no references.
+func ProviderFunctionRefs(expr hcl.Expression) []ProviderFunctionRef {
+	refs := make([]ProviderFunctionRef, 0)
+
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return refs
+	}
+
+	hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		call, ok := n.(*hclsyntax.FunctionCallExpr)
+		if !ok {
+			return nil
+		}
+
+		parts := strings.Split(call.Name, "::")
+		if len(parts) != 3 || parts[0] != "provider" || parts[1] == "" || parts[2] == "" {
+			return nil
+		}
+
+		refs = append(refs, ProviderFunctionRef{
+			LocalName: parts[1],
+			Function:  parts[2],
+			Range:     call.Range(),
+		})
+		return nil
+	})
+
+	return refs
+}
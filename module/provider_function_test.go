@@ -0,0 +1,44 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestProviderFunctionRefs(t *testing.T) {
+	expr := mustParseExpr(t, `"${provider::aws::arn_parse(var.arn).region}-${provider::time::rfc3339_parse(var.ts).year}-${upper(var.env)}"`)
+
+	refs := ProviderFunctionRefs(expr)
+	expectedRefs := []ProviderFunctionRef{
+		{
+			LocalName: "aws",
+			Function:  "arn_parse",
+			Range: hcl.Range{
+				Filename: "test.tf",
+				Start:    hcl.Pos{Line: 1, Column: 4, Byte: 3},
+				End:      hcl.Pos{Line: 1, Column: 37, Byte: 36},
+			},
+		},
+		{
+			LocalName: "time",
+			Function:  "rfc3339_parse",
+			Range: hcl.Range{
+				Filename: "test.tf",
+				Start:    hcl.Pos{Line: 1, Column: 48, Byte: 47},
+				End:      hcl.Pos{Line: 1, Column: 85, Byte: 84},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedRefs, refs); diff != "" {
+		t.Fatalf("unexpected references: %s", diff)
+	}
+}
+
+func TestProviderFunctionRefs_none(t *testing.T) {
+	refs := ProviderFunctionRefs(mustParseExpr(t, `upper(var.env)`))
+	if len(refs) != 0 {
+		t.Fatalf("expected no references, given: %#v", refs)
+	}
+}
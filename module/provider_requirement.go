@@ -0,0 +1,92 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// ProviderRequirement represents a provider requirement
+// as declared in the required_providers block (or implied
+// by a provider block) under a particular local name
+type ProviderRequirement struct {
+	Source               string
+	VersionConstraints   []string
+	ConfigurationAliases []ProviderRef
+
+	// DeclRange is the range of the first required_providers entry
+	// declaring the provider, e.g. aws = { source = "hashicorp/aws" }.
+	// It is empty if the requirement is only implied by other blocks.
+	DeclRange hcl.Range
+
+	// DeclRanges contains ranges of all required_providers entries
+	// declaring the provider, which may be spread across files,
+	// or nil if there are none
+	DeclRanges []hcl.Range
+}
+
+// MapKey returns a string which identifies the requirement by both
+// the given local name and its normalized (case-folded) source, such that the same
+// local name mapped to different sources yields different keys.
+// Requirements without a source are identified by the local name alone.
+func (pr *ProviderRequirement) MapKey(localName string) string {
+	if pr.Source == "" {
+		return localName
+	}
+
+	src := pr.Source
+	if ps, err := ParseProviderSource(strings.ToLower(pr.Source)); err == nil {
+		src = ps.String()
+	}
+	return fmt.Sprintf("%s=%s", localName, src)
+}
+
+// EffectiveConstraints parses and merges all version constraints
+// of the requirement, which may come from multiple files and from
+// both required_providers and provider blocks.
+//
+// The same constraint is commonly declared both in the provider block
+// and in required_providers, so identical ones are only kept once.
+// Unparsable constraints are skipped and reported as diagnostics.
+func (pr *ProviderRequirement) EffectiveConstraints() (version.Constraints, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	constraints := make(version.Constraints, 0)
+	seen := make(map[string]bool, 0)
+
+	for _, vc := range pr.VersionConstraints {
+		c, err := version.NewConstraint(vc)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unable to parse provider requirements",
+				Detail:   fmt.Sprintf("Constraint %q is not a valid constraint: %s", vc, err),
+			})
+			continue
+		}
+		for _, constraint := range c {
+			key := constraintKey(constraint)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			constraints = append(constraints, constraint)
+		}
+	}
+
+	return constraints, diags
+}
+
+// constraintKey returns a normalized form of the given parsed constraint,
+// which otherwise keeps the spacing of its original string, such that
+// e.g. ">=3.0", ">= 3.0" and " >= 3.0" are recognised as the same
+func constraintKey(c *version.Constraint) string {
+	raw := strings.TrimSpace(c.String())
+	v := strings.TrimLeft(raw, "<>=!~")
+	op := raw[:len(raw)-len(v)]
+	if op == "" {
+		op = "="
+	}
+	return op + " " + strings.TrimSpace(v)
+}
@@ -0,0 +1,59 @@
+package module
+
+import (
+	"testing"
+)
+
+func TestProviderRequirement_EffectiveConstraints(t *testing.T) {
+	pr := &ProviderRequirement{
+		Source:             "hashicorp/aws",
+		VersionConstraints: []string{">= 3.0", "< 4.0, != 3.2.0", "invalid"},
+	}
+
+	constraints, diags := pr.EffectiveConstraints()
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, %d given: %s", len(diags), diags)
+	}
+
+	expected := ">= 3.0,< 4.0, != 3.2.0"
+	if constraints.String() != expected {
+		t.Fatalf("expected %q, given: %q", expected, constraints.String())
+	}
+}
+
+func TestProviderRequirement_MapKey(t *testing.T) {
+	testCases := []struct {
+		source      string
+		expectedKey string
+	}{
+		{"", "datadog"},
+		{"DataDog/datadog", "datadog=registry.terraform.io/datadog/datadog"},
+		{"registry.terraform.io/datadog/datadog", "datadog=registry.terraform.io/datadog/datadog"},
+		{"data_dog/datadog", "datadog=data_dog/datadog"},
+	}
+
+	for _, tc := range testCases {
+		pr := &ProviderRequirement{Source: tc.source}
+		if key := pr.MapKey("datadog"); key != tc.expectedKey {
+			t.Fatalf("%q: expected %q, given: %q", tc.source, tc.expectedKey, key)
+		}
+	}
+}
+
+func TestProviderRequirement_EffectiveConstraintsDuplicates(t *testing.T) {
+	pr := &ProviderRequirement{
+		Source:             "hashicorp/google",
+		VersionConstraints: []string{">= 3.0", ">=3.0, < 5.0", "<5.0", "~> 4.1, >= 4.2", "~>4.1,>=4.2", "= 4.3", "4.3"},
+	}
+
+	constraints, diags := pr.EffectiveConstraints()
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+
+	// the first occurrence of each constraint is kept as declared
+	expected := ">= 3.0, < 5.0,~> 4.1, >= 4.2,= 4.3"
+	if constraints.String() != expected {
+		t.Fatalf("expected %q, given: %q", expected, constraints.String())
+	}
+}
@@ -0,0 +1,88 @@
+package module
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultProviderRegistryHost is the host of the public
+	// Terraform Registry, used when a provider source omits the host
+	DefaultProviderRegistryHost = "registry.terraform.io"
+
+	// DefaultProviderNamespace is the namespace implied
+	// for legacy single-segment provider sources
+	DefaultProviderNamespace = "hashicorp"
+)
+
+var (
+	providerHostRe = regexp.MustCompile(`^[0-9a-z](?:[0-9a-z-]*[0-9a-z])?(?:\.[0-9a-z](?:[0-9a-z-]*[0-9a-z])?)*(?::[0-9]+)?$`)
+	providerPartRe = regexp.MustCompile(`^[0-9a-z](?:[0-9a-z-]*[0-9a-z])?$`)
+)
+
+// ProviderSource represents a fully-qualified provider source address
+// in the hostname/namespace/type format
+type ProviderSource struct {
+	Hostname  string
+	Namespace string
+	Type      string
+}
+
+// String returns the canonical fully-qualified form of the source
+func (ps ProviderSource) String() string {
+	return ps.Hostname + "/" + ps.Namespace + "/" + ps.Type
+}
+
+// ParseProviderSource parses the given raw source in the
+// [hostname/][namespace/]type format, where the hostname is
+// registry.terraform.io and the namespace is hashicorp if omitted.
+//
+// Unlike Terraform, which folds the case of the given parts,
+// only lowercase sources are considered valid.
+func ParseProviderSource(raw string) (ProviderSource, error) {
+	parts := strings.Split(raw, "/")
+	if len(parts) > 3 {
+		return ProviderSource{}, fmt.Errorf("%q: provider source must have at most 3 segments, %d given",
+			raw, len(parts))
+	}
+
+	src := ProviderSource{
+		Hostname:  DefaultProviderRegistryHost,
+		Namespace: DefaultProviderNamespace,
+	}
+
+	switch len(parts) {
+	case 1:
+		src.Type = parts[0]
+	case 2:
+		src.Namespace, src.Type = parts[0], parts[1]
+	case 3:
+		src.Hostname, src.Namespace, src.Type = parts[0], parts[1], parts[2]
+	}
+
+	if !providerHostRe.MatchString(src.Hostname) {
+		return ProviderSource{}, fmt.Errorf("%q: invalid provider hostname %q", raw, src.Hostname)
+	}
+	if err := validateProviderPart(src.Namespace); err != nil {
+		return ProviderSource{}, fmt.Errorf("%q: invalid provider namespace: %w", raw, err)
+	}
+	if err := validateProviderPart(src.Type); err != nil {
+		return ProviderSource{}, fmt.Errorf("%q: invalid provider type: %w", raw, err)
+	}
+
+	return src, nil
+}
+
+func validateProviderPart(part string) error {
+	if part == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	if strings.ToLower(part) != part {
+		return fmt.Errorf("%q must be lowercase", part)
+	}
+	if !providerPartRe.MatchString(part) || strings.Contains(part, "--") {
+		return fmt.Errorf("%q must contain only letters, digits and single dashes", part)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package module
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseProviderSource(t *testing.T) {
+	testCases := []struct {
+		source         string
+		expectedSource ProviderSource
+		expectedFQN    string
+		expectedErr    string
+	}{
+		{
+			"aws",
+			ProviderSource{
+				Hostname:  "registry.terraform.io",
+				Namespace: "hashicorp",
+				Type:      "aws",
+			},
+			"registry.terraform.io/hashicorp/aws",
+			"",
+		},
+		{
+			"grafana/grafana",
+			ProviderSource{
+				Hostname:  "registry.terraform.io",
+				Namespace: "grafana",
+				Type:      "grafana",
+			},
+			"registry.terraform.io/grafana/grafana",
+			"",
+		},
+		{
+			"app.terraform.io/example-corp/k8s",
+			ProviderSource{
+				Hostname:  "app.terraform.io",
+				Namespace: "example-corp",
+				Type:      "k8s",
+			},
+			"app.terraform.io/example-corp/k8s",
+			"",
+		},
+		{
+			"localhost:8080/foo/bar",
+			ProviderSource{
+				Hostname:  "localhost:8080",
+				Namespace: "foo",
+				Type:      "bar",
+			},
+			"localhost:8080/foo/bar",
+			"",
+		},
+		{
+			"hashicorp/AWS",
+			ProviderSource{},
+			"",
+			`"hashicorp/AWS": invalid provider type: "AWS" must be lowercase`,
+		},
+		{
+			"Registry.terraform.io/hashicorp/aws",
+			ProviderSource{},
+			"",
+			`"Registry.terraform.io/hashicorp/aws": invalid provider hostname "Registry.terraform.io"`,
+		},
+		{
+			"hashi_corp/aws",
+			ProviderSource{},
+			"",
+			`"hashi_corp/aws": invalid provider namespace: "hashi_corp" must contain only letters, digits and single dashes`,
+		},
+		{
+			"hashicorp/aws--beta",
+			ProviderSource{},
+			"",
+			`"hashicorp/aws--beta": invalid provider type: "aws--beta" must contain only letters, digits and single dashes`,
+		},
+		{
+			"hashicorp/",
+			ProviderSource{},
+			"",
+			`"hashicorp/": invalid provider type: must not be empty`,
+		},
+		{
+			"example.com/a/b/c",
+			ProviderSource{},
+			"",
+			`"example.com/a/b/c": provider source must have at most 3 segments, 4 given`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%s", i, tc.source), func(t *testing.T) {
+			src, err := ParseProviderSource(tc.source)
+			if err != nil {
+				if tc.expectedErr == "" {
+					t.Fatal(err)
+				}
+				if err.Error() != tc.expectedErr {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			} else if tc.expectedErr != "" {
+				t.Fatalf("expected error: %s", tc.expectedErr)
+			}
+
+			if src != tc.expectedSource {
+				t.Fatalf("source mismatch.\nexpected: %#v\ngiven: %#v", tc.expectedSource, src)
+			}
+			if src.String() != tc.expectedFQN {
+				t.Fatalf("expected FQN %q, given: %q", tc.expectedFQN, src.String())
+			}
+		})
+	}
+}
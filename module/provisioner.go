@@ -0,0 +1,23 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Provisioner represents a provisioner block of a resource,
+// whose body is not decoded
+type Provisioner struct {
+	// Type is the provisioner type, such as local-exec
+	Type string
+
+	// WhenDestroy is true if when = destroy was declared,
+	// i.e. the provisioner runs before the resource is destroyed
+	WhenDestroy bool
+
+	// HasConnection is true if the provisioner
+	// declares its own connection block
+	HasConnection bool
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
@@ -0,0 +1,15 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Removed represents a removed block, which tells Terraform
+// to forget an object without necessarily destroying it
+type Removed struct {
+	From hcl.Traversal
+
+	// Destroy reflects the destroy argument of the nested lifecycle block
+	// and is true (Terraform's default) if not declared
+	Destroy bool
+}
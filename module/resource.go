@@ -0,0 +1,179 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-registry-address"
+)
+
+// Resource represents a single "resource" block within a module.
+type Resource struct {
+	Type string
+	Name string
+
+	Provider ProviderRef
+
+	// ProviderAddr is the address of the provider, resolved
+	// from Provider via the provider requirements of the module
+	ProviderAddr tfaddr.Provider
+
+	// Count and ForEach are nil unless the respective
+	// meta-argument was declared
+	Count   hcl.Expression
+	ForEach hcl.Expression
+
+	DependsOn []hcl.Traversal
+
+	// DynamicBlocks represents dynamic blocks declared
+	// at the top level of the body
+	DynamicBlocks []*DynamicBlock
+
+	// Lifecycle is nil unless the lifecycle block was declared
+	Lifecycle *Lifecycle
+
+	// Provisioners represents provisioner blocks in order
+	// of declaration and is nil unless any were declared
+	Provisioners []*Provisioner
+
+	// HasConnection is true if the resource declares
+	// a connection block shared by its provisioners
+	HasConnection bool
+
+	// Body is the body of the block without the meta-arguments
+	// and blocks above, which can be decoded further by the caller
+	// using a provider schema. It references only the parsed
+	// attributes and blocks, not the source of the file.
+	//
+	// Body is not serialized and is nil for metadata decoded from JSON.
+	Body hcl.Body
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// MapKey returns a string that can be used to uniquely identify the receiver
+// in a map[string]*Resource.
+func (r *Resource) MapKey() string {
+	return fmt.Sprintf("%s.%s", r.Type, r.Name)
+}
+
+// HasCount returns true if the resource declares count
+func (r *Resource) HasCount() bool {
+	return r.Count != nil
+}
+
+// HasForEach returns true if the resource declares for_each
+func (r *Resource) HasForEach() bool {
+	return r.ForEach != nil
+}
+
+// DataSource represents a single "data" block within a module.
+type DataSource struct {
+	Type string
+	Name string
+
+	Provider ProviderRef
+
+	// ProviderAddr is the address of the provider, resolved
+	// from Provider via the provider requirements of the module
+	ProviderAddr tfaddr.Provider
+
+	// Count and ForEach are nil unless the respective
+	// meta-argument was declared
+	Count   hcl.Expression
+	ForEach hcl.Expression
+
+	DependsOn []hcl.Traversal
+
+	// DynamicBlocks represents dynamic blocks declared
+	// at the top level of the body
+	DynamicBlocks []*DynamicBlock
+
+	// Lifecycle is nil unless the lifecycle block was declared
+	Lifecycle *Lifecycle
+
+	// Body is the body of the block without the meta-arguments
+	// and blocks above, which can be decoded further by the caller
+	// using a provider schema. It references only the parsed
+	// attributes and blocks, not the source of the file.
+	//
+	// Body is not serialized and is nil for metadata decoded from JSON.
+	Body hcl.Body
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// MapKey returns a string that can be used to uniquely identify the receiver
+// in a map[string]*DataSource.
+func (d *DataSource) MapKey() string {
+	return fmt.Sprintf("data.%s.%s", d.Type, d.Name)
+}
+
+// HasCount returns true if the data source declares count
+func (d *DataSource) HasCount() bool {
+	return d.Count != nil
+}
+
+// HasForEach returns true if the data source declares for_each
+func (d *DataSource) HasForEach() bool {
+	return d.ForEach != nil
+}
+
+// EphemeralResource represents a single "ephemeral" block within a module.
+// Ephemeral resources are not persisted to state and are therefore
+// kept separate from managed resources.
+type EphemeralResource struct {
+	Type string
+	Name string
+
+	Provider ProviderRef
+
+	// ProviderAddr is the address of the provider, resolved
+	// from Provider via the provider requirements of the module
+	ProviderAddr tfaddr.Provider
+
+	// Count and ForEach are nil unless the respective
+	// meta-argument was declared
+	Count   hcl.Expression
+	ForEach hcl.Expression
+
+	DependsOn []hcl.Traversal
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// MapKey returns a string that can be used to uniquely identify the receiver
+// in a map[string]*EphemeralResource.
+func (e *EphemeralResource) MapKey() string {
+	return fmt.Sprintf("ephemeral.%s.%s", e.Type, e.Name)
+}
+
+// HasCount returns true if the ephemeral resource declares count
+func (e *EphemeralResource) HasCount() bool {
+	return e.Count != nil
+}
+
+// HasForEach returns true if the ephemeral resource declares for_each
+func (e *EphemeralResource) HasForEach() bool {
+	return e.ForEach != nil
+}
+
+// DynamicBlock represents a "dynamic" block which generates
+// nested blocks of the given type
+type DynamicBlock struct {
+	// Type is the type of the generated blocks, i.e. the label
+	Type string
+
+	ForEach hcl.Expression
+
+	// Iterator is the name of the temporary variable representing
+	// the current element; it defaults to the block type
+	Iterator string
+
+	// Content is the body of the content block, i.e. the template
+	// for each generated block; nil if not declared
+	Content hcl.Body
+}
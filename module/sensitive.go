@@ -0,0 +1,82 @@
+package module
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// SensitiveLeak represents a reference to a sensitive variable
+// from the value of an output which is not marked as sensitive
+type SensitiveLeak struct {
+	Output   string
+	Variable string
+
+	// Range is the range of the reference to the variable
+	Range hcl.Range
+}
+
+// FindSensitiveLeaks returns references to sensitive variables
+// found in values of outputs which are not marked as sensitive,
+// sorted by output and variable name.
+//
+// Only direct references are considered, i.e. sensitive values
+// passed through locals or resources are not tracked.
+func FindSensitiveLeaks(meta *Meta) []SensitiveLeak {
+	leaks := make([]SensitiveLeak, 0)
+
+	for _, o := range meta.Outputs {
+		if o.IsSensitive || o.Value == nil {
+			continue
+		}
+
+		for _, ref := range variableReferences(o.Value) {
+			v, ok := meta.Variables[ref.Name]
+			if !ok || !v.IsSensitive {
+				continue
+			}
+
+			leaks = append(leaks, SensitiveLeak{
+				Output:   o.Name,
+				Variable: v.Name,
+				Range:    ref.Range,
+			})
+		}
+	}
+
+	sort.SliceStable(leaks, func(i, j int) bool {
+		if leaks[i].Output != leaks[j].Output {
+			return leaks[i].Output < leaks[j].Output
+		}
+		return leaks[i].Variable < leaks[j].Variable
+	})
+
+	return leaks
+}
+
+// variableReference represents a direct reference
+// to an input variable, such as var.foo
+type variableReference struct {
+	Name  string
+	Range hcl.Range
+}
+
+// variableReferences returns direct references to input
+// variables within the given expression, in order of appearance
+func variableReferences(expr hcl.Expression) []variableReference {
+	refs := make([]variableReference, 0)
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "var" || len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		refs = append(refs, variableReference{
+			Name:  attr.Name,
+			Range: traversal.SourceRange(),
+		})
+	}
+	return refs
+}
@@ -0,0 +1,60 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestFindSensitiveLeaks(t *testing.T) {
+	meta := &Meta{
+		Variables: map[string]*Variable{
+			"password": {Name: "password", IsSensitive: true},
+			"username": {Name: "username"},
+		},
+		Outputs: map[string]*Output{
+			"connection": {
+				Name:  "connection",
+				Value: mustParseExpr(t, `"${var.username}:${var.password}"`),
+			},
+			"password": {
+				Name:        "password",
+				IsSensitive: true,
+				Value:       mustParseExpr(t, `var.password`),
+			},
+			"username": {
+				Name:  "username",
+				Value: mustParseExpr(t, `var.username`),
+			},
+			"empty": {
+				Name: "empty",
+			},
+		},
+	}
+
+	leaks := FindSensitiveLeaks(meta)
+	expectedLeaks := []SensitiveLeak{
+		{
+			Output:   "connection",
+			Variable: "password",
+			Range: hcl.Range{
+				Filename: "test.tf",
+				Start:    hcl.Pos{Line: 1, Column: 20, Byte: 19},
+				End:      hcl.Pos{Line: 1, Column: 32, Byte: 31},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedLeaks, leaks); diff != "" {
+		t.Fatalf("unexpected leaks: %s", diff)
+	}
+}
+
+func mustParseExpr(t *testing.T, src string) hcl.Expression {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+	if len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	return expr
+}
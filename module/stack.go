@@ -0,0 +1,72 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Stack represents a Terraform Stacks configuration file (*.tfstack.hcl)
+type Stack struct {
+	Components map[string]*StackComponent
+
+	// RequiredProviders represents the provider requirements
+	// of the stack, keyed by their local names
+	RequiredProviders map[string]*ProviderRequirement
+
+	// Variables and Outputs contain sorted names
+	// of variables and outputs of the stack
+	Variables []string
+	Outputs   []string
+}
+
+// StackComponent represents a component block within a stack
+type StackComponent struct {
+	Name   string
+	Source string
+
+	// Version represents the raw version constraint
+	// of registry modules, as declared
+	Version string
+
+	// Inputs and Providers contain sorted keys
+	// of the inputs and providers arguments
+	Inputs    []string
+	Providers []string
+
+	// ComponentReferences contains sorted names of other components
+	// referenced by inputs, e.g. via component.network.subnet_ids
+	ComponentReferences []string
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// Kind returns the kind of the source, classified
+// the same way as sources of module calls
+func (sc *StackComponent) Kind() ModuleSourceKind {
+	return sc.ParsedSource().Kind()
+}
+
+// ParsedSource returns the source parsed into a typed struct
+// corresponding to its kind.
+func (sc *StackComponent) ParsedSource() ParsedModuleSource {
+	return ParseModuleSource(sc.Source)
+}
+
+// Deployment represents a Terraform Stacks deployment file (*.tfdeploy.hcl)
+type Deployment struct {
+	Deployments map[string]*StackDeployment
+
+	// IdentityTokens contains sorted names of identity_token blocks
+	IdentityTokens []string
+}
+
+// StackDeployment represents a deployment block within a deployment file
+type StackDeployment struct {
+	Name string
+
+	// Inputs contains sorted names of inputs passed to the stack
+	Inputs []string
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
@@ -0,0 +1,64 @@
+package module
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// TestFile represents a Terraform test file (*.tftest.hcl)
+type TestFile struct {
+	// Variables contains values of variables
+	// shared by all run blocks in the file
+	Variables map[string]hcl.Expression
+
+	// Providers contains references to provider configurations
+	// declared at the top level of the file
+	Providers []ProviderRef
+
+	MockProviders []*MockProvider
+
+	Runs []*TestRun
+}
+
+// MockProvider represents a mock_provider block within a test file
+type MockProvider struct {
+	LocalName string
+	Alias     string
+
+	// MockResources and MockDataSources contain sorted types
+	// of resources and data sources mocked by the provider
+	MockResources   []string
+	MockDataSources []string
+
+	// OverrideTargets contains addresses of resources
+	// and data sources overridden by the provider
+	OverrideTargets []hcl.Traversal
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// TestRun represents a run block within a test file
+type TestRun struct {
+	Name string
+
+	// Command is either "plan" or "apply" (default)
+	Command string
+
+	// Module is the module under test, or nil
+	// if the run block tests the main module
+	Module *TestRunModule
+
+	// Variables contains values of variables specific to the run block
+	Variables map[string]hcl.Expression
+
+	Assertions []*CheckRule
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// TestRunModule represents a module block within a run block
+type TestRunModule struct {
+	Source  string
+	Version string
+}
@@ -0,0 +1,67 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-registry-address"
+)
+
+// SchemaSource provides information about resource and data source
+// types supported by providers, e.g. from provider schemas
+type SchemaSource interface {
+	// HasProvider returns true if the schema of the given provider
+	// is available, such that its types can be checked
+	HasProvider(pAddr tfaddr.Provider) bool
+
+	// ResourceType returns true if the provider supports
+	// the given managed resource type
+	ResourceType(pAddr tfaddr.Provider, typeName string) bool
+
+	// DataSourceType returns true if the provider supports
+	// the given data source type
+	DataSourceType(pAddr tfaddr.Provider, typeName string) bool
+}
+
+// ValidateAgainstSchema checks that resource and data source types
+// of the module are supported by their providers.
+//
+// Resources and data sources of providers which couldn't be resolved,
+// or whose schema isn't available from the given source, are skipped.
+func ValidateAgainstSchema(meta *Meta, schemas SchemaSource) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for _, r := range meta.SortedResources() {
+		if !isValidatable(r.ProviderAddr, schemas) {
+			continue
+		}
+		if !schemas.ResourceType(r.ProviderAddr, r.Type) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown resource type",
+				Detail:   fmt.Sprintf("The provider %s does not support resource type %q.", r.ProviderAddr.ForDisplay(), r.Type),
+				Subject:  r.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	for _, ds := range meta.SortedDataSources() {
+		if !isValidatable(ds.ProviderAddr, schemas) {
+			continue
+		}
+		if !schemas.DataSourceType(ds.ProviderAddr, ds.Type) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown data source type",
+				Detail:   fmt.Sprintf("The provider %s does not support data source type %q.", ds.ProviderAddr.ForDisplay(), ds.Type),
+				Subject:  ds.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+func isValidatable(pAddr tfaddr.Provider, schemas SchemaSource) bool {
+	return !pAddr.IsZero() && schemas.HasProvider(pAddr)
+}
@@ -0,0 +1,99 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-registry-address"
+)
+
+type stubSchemaSource struct {
+	resources   map[tfaddr.Provider][]string
+	dataSources map[tfaddr.Provider][]string
+}
+
+func (s *stubSchemaSource) HasProvider(pAddr tfaddr.Provider) bool {
+	_, ok := s.resources[pAddr]
+	return ok
+}
+
+func (s *stubSchemaSource) ResourceType(pAddr tfaddr.Provider, typeName string) bool {
+	return containsString(s.resources[pAddr], typeName)
+}
+
+func (s *stubSchemaSource) DataSourceType(pAddr tfaddr.Provider, typeName string) bool {
+	return containsString(s.dataSources[pAddr], typeName)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateAgainstSchema(t *testing.T) {
+	aws := tfaddr.NewDefaultProvider("aws")
+	google := tfaddr.NewDefaultProvider("google")
+
+	meta := &Meta{
+		Resources: map[string]*Resource{
+			"aws_instance.web": {
+				Type:         "aws_instance",
+				Name:         "web",
+				ProviderAddr: aws,
+			},
+			"aws_instanse.typo": {
+				Type:         "aws_instanse",
+				Name:         "typo",
+				ProviderAddr: aws,
+			},
+			"google_foo.bar": {
+				Type:         "google_foo",
+				Name:         "bar",
+				ProviderAddr: google,
+			},
+			"unresolved_foo.bar": {
+				Type: "unresolved_foo",
+				Name: "bar",
+			},
+		},
+		DataSources: map[string]*DataSource{
+			"data.aws_ami.ubuntu": {
+				Type:         "aws_ami",
+				Name:         "ubuntu",
+				ProviderAddr: aws,
+			},
+			"data.aws_instance.web": {
+				Type:         "aws_instance",
+				Name:         "web",
+				ProviderAddr: aws,
+			},
+		},
+	}
+
+	schemas := &stubSchemaSource{
+		resources: map[tfaddr.Provider][]string{
+			aws: {"aws_instance"},
+		},
+		dataSources: map[tfaddr.Provider][]string{
+			aws: {"aws_ami"},
+		},
+	}
+
+	diags := ValidateAgainstSchema(meta, schemas)
+
+	details := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		details = append(details, diag.Detail)
+	}
+	expectedDetails := []string{
+		`The provider hashicorp/aws does not support resource type "aws_instanse".`,
+		`The provider hashicorp/aws does not support data source type "aws_instance".`,
+	}
+	if diff := cmp.Diff(expectedDetails, details); diff != "" {
+		t.Fatalf("unexpected diagnostics: %s", diff)
+	}
+}
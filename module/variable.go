@@ -0,0 +1,44 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type Variable struct {
+	Name        string
+	Description string
+
+	// Type represents the type constraint of the variable.
+	// It is cty.DynamicPseudoType if the type was not declared
+	// or could not be decoded.
+	Type cty.Type
+
+	// DefaultValue is cty.NilVal if no default was declared.
+	DefaultValue cty.Value
+
+	IsSensitive bool
+
+	// Ephemeral is true if the variable was declared with
+	// ephemeral = true, i.e. its value is not persisted
+	Ephemeral bool
+
+	// IsNullable is true unless nullable = false was declared
+	IsNullable bool
+
+	// Validations represents validation blocks, whose conditions
+	// refer to the variable as var.<name>. It is nil unless any
+	// were declared.
+	Validations []*CheckRule
+
+	// DeclRange is the range of the block header
+	DeclRange hcl.Range
+}
+
+// MapKey returns a string that can be used to uniquely identify the receiver
+// in a map[string]*Variable.
+func (v *Variable) MapKey() string {
+	return fmt.Sprintf("var.%s", v.Name)
+}
@@ -0,0 +1,56 @@
+package module
+
+// Visitor is implemented by callers of Meta.Walk
+// which want to visit all decoded entities uniformly.
+//
+// Implementations can embed BaseVisitor and only
+// override the methods they're interested in.
+type Visitor interface {
+	VisitProvider(*ProviderConfig)
+	VisitResource(*Resource)
+	VisitDataSource(*DataSource)
+	VisitEphemeralResource(*EphemeralResource)
+	VisitModuleCall(*ModuleSource)
+	VisitVariable(*Variable)
+	VisitOutput(*Output)
+}
+
+// BaseVisitor implements Visitor with no-op methods
+type BaseVisitor struct{}
+
+func (BaseVisitor) VisitProvider(*ProviderConfig)             {}
+func (BaseVisitor) VisitResource(*Resource)                   {}
+func (BaseVisitor) VisitDataSource(*DataSource)               {}
+func (BaseVisitor) VisitEphemeralResource(*EphemeralResource) {}
+func (BaseVisitor) VisitModuleCall(*ModuleSource)             {}
+func (BaseVisitor) VisitVariable(*Variable)                   {}
+func (BaseVisitor) VisitOutput(*Output)                       {}
+
+// Walk calls the visitor for each provider configuration, resource,
+// data source, ephemeral resource, module call, variable and output,
+// in that order. Entities of each kind are visited in the order
+// of ProviderConfigs and of their map keys respectively,
+// such that the walk is deterministic.
+func (m *Meta) Walk(visitor Visitor) {
+	for _, pc := range m.ProviderConfigs {
+		visitor.VisitProvider(pc)
+	}
+	for _, r := range m.SortedResources() {
+		visitor.VisitResource(r)
+	}
+	for _, ds := range m.SortedDataSources() {
+		visitor.VisitDataSource(ds)
+	}
+	for _, er := range m.SortedEphemeralResources() {
+		visitor.VisitEphemeralResource(er)
+	}
+	for _, ms := range m.SortedModuleSources() {
+		visitor.VisitModuleCall(ms)
+	}
+	for _, v := range m.SortedVariables() {
+		visitor.VisitVariable(v)
+	}
+	for _, o := range m.SortedOutputs() {
+		visitor.VisitOutput(o)
+	}
+}
@@ -0,0 +1,65 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// countingVisitor counts visited entities of each kind
+type countingVisitor struct {
+	BaseVisitor
+	counts map[string]int
+}
+
+func (v *countingVisitor) VisitProvider(*ProviderConfig) { v.counts["provider"]++ }
+func (v *countingVisitor) VisitResource(*Resource)       { v.counts["resource"]++ }
+func (v *countingVisitor) VisitDataSource(*DataSource)   { v.counts["data"]++ }
+func (v *countingVisitor) VisitModuleCall(*ModuleSource) { v.counts["module"]++ }
+func (v *countingVisitor) VisitVariable(*Variable)       { v.counts["variable"]++ }
+func (v *countingVisitor) VisitOutput(*Output)           { v.counts["output"]++ }
+
+func TestMeta_Walk(t *testing.T) {
+	meta := &Meta{
+		ProviderConfigs: []*ProviderConfig{
+			{LocalName: "aws"},
+			{LocalName: "aws", Alias: "west"},
+		},
+		Resources: map[string]*Resource{
+			"aws_instance.db":  {Type: "aws_instance", Name: "db"},
+			"aws_instance.web": {Type: "aws_instance", Name: "web"},
+		},
+		DataSources: map[string]*DataSource{
+			"data.aws_ami.ubuntu": {Type: "aws_ami", Name: "ubuntu"},
+		},
+		EphemeralResources: map[string]*EphemeralResource{
+			"ephemeral.aws_secretsmanager_secret_version.db": {Type: "aws_secretsmanager_secret_version", Name: "db"},
+		},
+		ModuleSources: map[string]*ModuleSource{
+			"module.vpc": {Name: "vpc", Source: "./modules/vpc"},
+		},
+		Variables: map[string]*Variable{
+			"name": {Name: "name"},
+			"tags": {Name: "tags"},
+		},
+		Outputs: map[string]*Output{
+			"web_id": {Name: "web_id"},
+		},
+	}
+
+	// ephemeral resources are left to the embedded no-op visitor
+	visitor := &countingVisitor{counts: make(map[string]int, 0)}
+	meta.Walk(visitor)
+
+	expectedCounts := map[string]int{
+		"provider": 2,
+		"resource": 2,
+		"data":     1,
+		"module":   1,
+		"variable": 2,
+		"output":   1,
+	}
+	if diff := cmp.Diff(expectedCounts, visitor.counts); diff != "" {
+		t.Fatalf("unexpected counts: %s", diff)
+	}
+}